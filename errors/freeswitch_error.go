@@ -0,0 +1,11 @@
+package errors
+
+import "fmt"
+
+type ConnectionLostError struct {
+	error
+}
+
+func NewConnectionLostError(cause error) *ConnectionLostError {
+	return &ConnectionLostError{error: fmt.Errorf("freeswitch connection lost: %v", cause)}
+}
@@ -0,0 +1,88 @@
+package freeswitch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	res     string
+	expires time.Time
+}
+
+// apiCache keeps replies of idempotent read commands for a short while. Any
+// other command sent through the client drops every entry, so a read never
+// outlives a write that may have changed its answer.
+type apiCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func newApiCache(ttl time.Duration) *apiCache {
+	return &apiCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func cacheKey(cmd *Command) string {
+	return fmt.Sprintf("%v %v", cmd.AppName, cmd.AppArgs)
+}
+
+func (c *apiCache) get(cmd *Command) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(cmd)
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+
+	return e.res, true
+}
+
+func (c *apiCache) put(cmd *Command, res string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[cacheKey(cmd)] = cacheEntry{res: res, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *apiCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+}
+
+// EnableApiCache caches replies of idempotent read commands for ttl. A
+// non-positive ttl turns caching off.
+func (s *SocketClientImpl) EnableApiCache(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ttl <= 0 {
+		s.cache = nil
+		return
+	}
+
+	s.cache = newApiCache(ttl)
+}
+
+func (s *SocketClientImpl) apiCache() *apiCache {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.cache
+}
+
+func (s *SocketClientImpl) invalidateCache() {
+	if cache := s.apiCache(); cache != nil {
+		cache.clear()
+	}
+}
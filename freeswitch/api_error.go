@@ -0,0 +1,46 @@
+package freeswitch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	CauseNoSuchChannel = "NO_SUCH_CHANNEL"
+	CauseUsage         = "USAGE"
+)
+
+type ApiError struct {
+	Command  string
+	Response string
+	Cause    string
+}
+
+func NewApiError(command, response string) *ApiError {
+	return &ApiError{Command: command, Response: response, Cause: parseCause(response)}
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("failed to execute api '%v': %v", e.Command, e.Response)
+}
+
+// IsApiCause reports whether err is an ApiError with the given cause.
+func IsApiCause(err error, cause string) bool {
+	var apiErr *ApiError
+	return errors.As(err, &apiErr) && apiErr.Cause == cause
+}
+
+// parseCause turns the text of a "-ERR <reason>" reply into an upper snake
+// case cause, so "No such channel!" becomes NO_SUCH_CHANNEL.
+func parseCause(res string) string {
+	if strings.HasPrefix(res, string(Syntax)) {
+		return CauseUsage
+	}
+
+	fields := strings.FieldsFunc(strings.ToUpper(res), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+	})
+
+	return strings.Join(fields, "_")
+}
@@ -0,0 +1,45 @@
+package freeswitch
+
+// AutoAnswerHeaders lists, per phone vendor, the SIP headers asking the phone
+// to pick up on its own. Entries can be added or replaced at startup.
+var AutoAnswerHeaders = map[string]map[string]string{
+	"generic": {
+		"Call-Info":  "answer-after=0",
+		"Alert-Info": "info=alert-autoanswer",
+	},
+	"polycom": {
+		"Alert-Info": "Ring Answer",
+	},
+	"yealink": {
+		"Call-Info": "answer-after=0",
+	},
+	"cisco": {
+		"Call-Info": "<sip:127.0.0.1>;answer-after=0",
+	},
+	"snom": {
+		"Call-Info":  "<sip:127.0.0.1>;answer-after=0",
+		"Alert-Info": "<http://127.0.0.1>;info=alert-autoanswer;delay=0",
+	},
+	"grandstream": {
+		"Call-Info":  "answer-after=0",
+		"Alert-Info": "info=alert-autoanswer",
+	},
+}
+
+const DefaultAutoAnswerVendor = "generic"
+
+// autoAnswerVariables renders the channel variables making vendor's phones
+// auto answer, falling back to the generic headers for unknown vendors.
+func autoAnswerVariables(vendor string) map[string]string {
+	headers, ok := AutoAnswerHeaders[vendor]
+	if !ok {
+		headers = AutoAnswerHeaders[DefaultAutoAnswerVendor]
+	}
+
+	vars := map[string]string{"sip_auto_answer": "true"}
+	for k, v := range headers {
+		vars["sip_h_"+k] = EscapeVar(v)
+	}
+
+	return vars
+}
@@ -0,0 +1,118 @@
+package freeswitch
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/percipia/eslgo"
+	"github.com/percipia/eslgo/command"
+)
+
+type bgApiCommand struct {
+	Command   string
+	Arguments string
+	JobUUID   string
+}
+
+func (b *bgApiCommand) BuildMessage() string {
+	return fmt.Sprintf("bgapi %s %s\r\nJob-UUID: %s", b.Command, b.Arguments, b.JobUUID)
+}
+
+type pendingJob struct {
+	result chan string
+	done   chan struct{}
+}
+
+func (s *SocketClientImpl) BgApi(ctx context.Context, cmd *Command) (string, <-chan string, error) {
+	s.invalidateCache()
+	if err := s.listenJobs(ctx); err != nil {
+		return "", nil, err
+	}
+
+	jobUUID := uuid.New().String()
+	job := &pendingJob{result: make(chan string, 1), done: make(chan struct{})}
+
+	s.mu.Lock()
+	s.jobs[jobUUID] = job
+	s.mu.Unlock()
+
+	raw, err := s.sendCommand(ctx, &bgApiCommand{Command: cmd.AppName, Arguments: cmd.AppArgs, JobUUID: jobUUID}, false)
+	if err != nil {
+		s.dropJob(jobUUID)
+		return "", nil, err
+	}
+
+	res, ok := NewResponse(raw).Get()
+	if !ok {
+		s.dropJob(jobUUID)
+		return "", nil, fmt.Errorf("failed to execute bgapi '%v': %v", cmd.AppName, res)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.dropJob(jobUUID)
+		case <-job.done:
+		}
+	}()
+
+	return jobUUID, job.result, nil
+}
+
+// listenJobs subscribes the connection to BACKGROUND_JOB once and installs the
+// listener delivering each job result to its pending caller.
+func (s *SocketClientImpl) listenJobs(ctx context.Context) error {
+	s.mu.Lock()
+	if s.jobs != nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.jobs = make(map[string]*pendingJob)
+	s.mu.Unlock()
+
+	raw, err := s.subscribe(ctx, &command.Event{Format: "plain", Listen: []string{"BACKGROUND_JOB"}})
+	if err == nil {
+		if res, ok := NewResponse(raw).Get(); !ok {
+			err = fmt.Errorf("failed to listen to background jobs: %v", res)
+		}
+	}
+
+	if err != nil {
+		s.mu.Lock()
+		s.jobs = nil
+		s.mu.Unlock()
+		return err
+	}
+
+	s.EventListener(eslgo.EventListenAll, func(e *Event) {
+		if e.GetName() != "BACKGROUND_JOB" {
+			return
+		}
+
+		jobUUID := e.GetHeader("Job-UUID")
+
+		s.mu.Lock()
+		job, ok := s.jobs[jobUUID]
+		delete(s.jobs, jobUUID)
+		s.mu.Unlock()
+
+		if ok {
+			job.result <- removeUnwantedChars(string(e.Body))
+			close(job.done)
+		}
+	})
+
+	return nil
+}
+
+func (s *SocketClientImpl) dropJob(jobUUID string) {
+	s.mu.Lock()
+	job, ok := s.jobs[jobUUID]
+	delete(s.jobs, jobUUID)
+	s.mu.Unlock()
+
+	if ok {
+		close(job.result)
+		close(job.done)
+	}
+}
@@ -0,0 +1,96 @@
+package freeswitch
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// channelLocks serializes commands per channel, so concurrent activities
+// touching one leg, e.g. hold and bridge, reach it in order.
+type channelLocks struct {
+	mu    sync.Mutex
+	locks map[string]*channelLock
+}
+
+type channelLock struct {
+	held chan struct{}
+	refs int
+}
+
+func newChannelLocks() *channelLocks {
+	return &channelLocks{locks: make(map[string]*channelLock)}
+}
+
+func (c *channelLocks) lock(ctx context.Context, uid string) (func(), error) {
+	c.mu.Lock()
+	l, ok := c.locks[uid]
+	if !ok {
+		l = &channelLock{held: make(chan struct{}, 1)}
+		c.locks[uid] = l
+	}
+	l.refs++
+	c.mu.Unlock()
+
+	select {
+	case l.held <- struct{}{}:
+		return func() {
+			<-l.held
+			c.release(uid, l)
+		}, nil
+	case <-ctx.Done():
+		c.release(uid, l)
+		return nil, ctx.Err()
+	}
+}
+
+func (c *channelLocks) release(uid string, l *channelLock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if l.refs--; l.refs == 0 {
+		delete(c.locks, uid)
+	}
+}
+
+// commandChannel is the channel cmd targets: its Uid, or the first argument
+// of uuid_ api commands.
+func commandChannel(cmd *Command) string {
+	if cmd.Uid != "" {
+		return cmd.Uid
+	}
+
+	if strings.HasPrefix(cmd.AppName, "uuid_") {
+		if fields := strings.Fields(cmd.AppArgs); len(fields) > 0 {
+			return strings.Trim(fields[0], "'")
+		}
+	}
+
+	return ""
+}
+
+// SerializeChannelCommands makes Execute and Api run the commands targeting
+// one channel one at a time, while other channels stay parallel.
+func (s *SocketClientImpl) SerializeChannelCommands(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !enabled {
+		s.channels = nil
+	} else if s.channels == nil {
+		s.channels = newChannelLocks()
+	}
+}
+
+func (s *SocketClientImpl) lockChannel(ctx context.Context, cmd *Command) (func(), error) {
+	s.mu.RLock()
+	channels := s.channels
+	s.mu.RUnlock()
+
+	uid := commandChannel(cmd)
+	if channels == nil || uid == "" {
+		return func() {}, nil
+	}
+
+	return channels.lock(ctx, uid)
+}
@@ -0,0 +1,80 @@
+package freeswitch
+
+import (
+	"regexp"
+)
+
+// CommandLogger receives every command sent over ESL and its reply. A
+// *log.Logger fits, as does zap.NewStdLog.
+type CommandLogger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Redactor masks sensitive parts of a command before it is logged.
+type Redactor func(string) string
+
+const redacted = "***"
+
+var (
+	secretPattern = regexp.MustCompile(`(?i)((?:password|passwd|secret|pass)\s*[=:]\s*)[^\s,}\]]+`)
+	numberPattern = regexp.MustCompile(`\+?\d{7,}`)
+)
+
+// DefaultRedactor masks passwords and anything that looks like a phone number.
+func DefaultRedactor(s string) string {
+	s = secretPattern.ReplaceAllString(s, "${1}"+redacted)
+	return numberPattern.ReplaceAllString(s, redacted)
+}
+
+// NewRedactor masks every match of patterns, on top of DefaultRedactor.
+func NewRedactor(patterns ...*regexp.Regexp) Redactor {
+	return func(s string) string {
+		s = DefaultRedactor(s)
+		for _, p := range patterns {
+			s = p.ReplaceAllString(s, redacted)
+		}
+
+		return s
+	}
+}
+
+// SetCommandLogger logs commands and replies through logger once redacted. A
+// nil redactor falls back to DefaultRedactor, a nil logger turns logging off.
+func (s *SocketClientImpl) SetCommandLogger(logger CommandLogger, redactor Redactor) {
+	if redactor == nil {
+		redactor = DefaultRedactor
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cmdLogger = logger
+	s.redactor = redactor
+}
+
+func (s *SocketClientImpl) logCommand(msg string) {
+	s.mu.RLock()
+	logger, redactor := s.cmdLogger, s.redactor
+	s.mu.RUnlock()
+
+	if logger != nil {
+		logger.Printf("ESL >> %v", redactor(msg))
+	}
+}
+
+func (s *SocketClientImpl) logReply(msg string, reply string, err error) {
+	s.mu.RLock()
+	logger, redactor := s.cmdLogger, s.redactor
+	s.mu.RUnlock()
+
+	if logger == nil {
+		return
+	}
+
+	if err != nil {
+		logger.Printf("ESL << %v: %v", redactor(msg), redactor(err.Error()))
+		return
+	}
+
+	logger.Printf("ESL << %v: %v", redactor(msg), redactor(reply))
+}
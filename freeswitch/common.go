@@ -2,6 +2,7 @@ package freeswitch
 
 import (
 	"context"
+	"fmt"
 	"github.com/percipia/eslgo"
 	"strings"
 	"time"
@@ -14,6 +15,20 @@ const (
 	Outbound Direction = "outbound"
 )
 
+func (d Direction) String() string {
+	return string(d)
+}
+
+// ParseDirection accepts inbound or outbound in any case.
+func ParseDirection(s string) (Direction, error) {
+	switch d := Direction(strings.ToLower(strings.TrimSpace(s))); d {
+	case Inbound, Outbound:
+		return d, nil
+	default:
+		return "", fmt.Errorf("invalid direction '%v'", s)
+	}
+}
+
 type Status string
 
 const (
@@ -30,17 +45,19 @@ func NewResponse(res *eslgo.RawResponse) *Response {
 	return &Response{RawResponse: res}
 }
 
+// Text is the raw reply, the body of api replies or the Reply-Text of others.
+func (c *Response) Text() string {
+	if c.Body != nil {
+		return string(c.Body)
+	}
+
+	return c.GetHeader("Reply-Text")
+}
+
 func (c *Response) Get() (string, bool) {
-	var body string
-	if c.Body == nil {
-		if c.HasHeader("Reply-Text") {
-			body = c.GetHeader("Reply-Text")
-		}
-		if body == "" {
-			return "", false
-		}
-	} else {
-		body = string(c.Body)
+	body := c.Text()
+	if body == "" {
+		return "", false
 	}
 
 	res, found := strings.CutPrefix(body, string(Failure))
@@ -185,6 +202,26 @@ type Command struct {
 	AppName string `json:"appName"`
 	AppArgs string `json:"appArgs"`
 	Uid     string `json:"uid"`
+	Loops   int    `json:"loops"`
+
+	Timeout time.Duration `json:"timeout"`
+	// NoCache skips the Api cache, for reads polling a changing value.
+	NoCache bool `json:"noCache"`
+}
+
+const DefaultCommandTimeout = 30 * time.Second
+
+const DestinationVariable = "fsflow_destination"
+
+// TimeoutSeconds converts d into the whole seconds FreeSWITCH timeouts such
+// as originate_timeout expect, rounding up and never going below one second.
+func TimeoutSeconds(d time.Duration) int {
+	s := int((d + time.Second - 1) / time.Second)
+	if s < 1 {
+		return 1
+	}
+
+	return s
 }
 
 type Originator struct {
@@ -193,6 +230,7 @@ type Originator struct {
 	Background  bool
 	Callback    string
 	Direction   Direction
+	Domain      string
 	ANI         string
 	DNIS        string
 	Gateway     string
@@ -200,7 +238,19 @@ type Originator struct {
 	Timeout     time.Duration
 	Extension   string
 	SessionId   string
+	UniqueId    string
 	Variables   map[string]interface{}
+
+	Destinations []string
+	Sequential   bool
+
+	// Endpoint dials a registered phone instead of DNIS through Gateway,
+	// AutoAnswer then asks the phone to pick up using AutoAnswerVendor's headers.
+	Endpoint         string
+	AutoAnswerVendor string
+
+	// DialTemplate overrides the dial string, see DialString.
+	DialTemplate string
 }
 
 type EventListener func(req *Event)
@@ -215,16 +265,24 @@ type ServerEventHandler interface {
 
 type SocketClient interface {
 	Execute(ctx context.Context, cmd *Command) (string, error)
+	// Originate returns the reply of a foreground originate as is, to be read
+	// with ParseOriginateResponse, and the job uuid of a background one.
 	Originate(ctx context.Context, o *Originator) (string, error)
 	Api(ctx context.Context, cmd *Command) (string, error)
-	BgApi(ctx context.Context, cmd *Command) (string, error)
+	BgApi(ctx context.Context, cmd *Command) (string, <-chan string, error)
 	AllEvents(ctx context.Context) error
 	MyEvents(ctx context.Context, id string) error
 	EventListener(id string, listener EventListener) string
+	RemoveEventListener(id, listenerId string)
+	Subscribe(ctx context.Context, eventNames []string) (<-chan *Event, error)
+	SubscribeWithOptions(ctx context.Context, eventNames []string, opts SubscribeOptions) (<-chan *Event, error)
+	DroppedEvents() uint64
 	SendEvent(ctx context.Context, cmd *Command) (string, error)
 	AddFilter(ctx context.Context, header, value string) error
 	DelFilter(ctx context.Context, header, value string) error
-	Close()
+	Ping(ctx context.Context) error
+	SetCommandLogger(logger CommandLogger, redactor Redactor)
+	Close() error
 }
 
 type SocketServer interface {
@@ -8,4 +8,15 @@ type Config struct {
 	Password string        `yaml:"password"`
 	Timeout  time.Duration `yaml:"timeout"`
 	ListenOn uint16        `yaml:"listen_on"`
+
+	Reconnect ReconnectPolicy `yaml:"reconnect"`
+	// Keepalive defaults to DefaultKeepaliveInterval, a negative value disables it.
+	Keepalive time.Duration `yaml:"keepalive"`
+	PoolSize  int           `yaml:"pool_size"`
+	// ApiCacheTTL caches idempotent read commands for that long, zero disables it.
+	ApiCacheTTL time.Duration `yaml:"api_cache_ttl"`
+
+	Events SubscribeOptions `yaml:"events"`
+	// SerializeChannels runs the commands targeting one channel in order.
+	SerializeChannels bool `yaml:"serialize_channels"`
 }
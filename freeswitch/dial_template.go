@@ -0,0 +1,72 @@
+package freeswitch
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+const DefaultDialTemplate = "sofia/{{.Profile}}/{{.Destination}}@{{.Gateway}}"
+
+type DialTarget struct {
+	Destination string
+	Gateway     string
+	Profile     string
+}
+
+var (
+	dialTemplatesMu sync.RWMutex
+	dialTemplates   = map[string]*template.Template{}
+	defaultDial     = template.Must(template.New("default").Parse(DefaultDialTemplate))
+)
+
+// RegisterDialTemplate sets how calls through gateway are dialed, e.g.
+// "sofia/gateway/{{.Gateway}}/+{{.Destination}}".
+func RegisterDialTemplate(gateway, text string) error {
+	t, err := parseDialTemplate(gateway, text)
+	if err != nil {
+		return err
+	}
+
+	dialTemplatesMu.Lock()
+	defer dialTemplatesMu.Unlock()
+
+	dialTemplates[gateway] = t
+
+	return nil
+}
+
+func parseDialTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dial template for '%v': %w", name, err)
+	}
+
+	return t, nil
+}
+
+// DialString renders target with text when given, else with the template
+// registered for the gateway, else with DefaultDialTemplate.
+func DialString(text string, target DialTarget) (string, error) {
+	t := defaultDial
+	if text != "" {
+		var err error
+		if t, err = parseDialTemplate(target.Gateway, text); err != nil {
+			return "", err
+		}
+	} else {
+		dialTemplatesMu.RLock()
+		if registered, ok := dialTemplates[target.Gateway]; ok {
+			t = registered
+		}
+		dialTemplatesMu.RUnlock()
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, target); err != nil {
+		return "", fmt.Errorf("cannot render dial string for '%v': %w", target.Gateway, err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,82 @@
+package freeswitch
+
+import (
+	"testing"
+)
+
+func TestDialString(t *testing.T) {
+	target := DialTarget{Destination: "84901234567", Gateway: "carrier", Profile: "external"}
+
+	tests := []struct {
+		name    string
+		text    string
+		target  DialTarget
+		want    string
+		wantErr bool
+	}{
+		{name: "default", target: target, want: "sofia/external/84901234567@carrier"},
+		{name: "gateway form", text: "sofia/gateway/{{.Gateway}}/{{.Destination}}", target: target, want: "sofia/gateway/carrier/84901234567"},
+		{name: "prefix", text: "sofia/{{.Profile}}/+{{.Destination}}@{{.Gateway}}", target: target, want: "sofia/external/+84901234567@carrier"},
+		{name: "endpoint", text: "user/{{.Destination}}", target: DialTarget{Destination: "1001"}, want: "user/1001"},
+		{name: "parse error", text: "sofia/{{.Profile", target: target, wantErr: true},
+		{name: "unknown field", text: "sofia/{{.Trunk}}/{{.Destination}}", target: target, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DialString(tt.text, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DialString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DialString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterDialTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		gateway  string
+		template string
+		text     string
+		want     string
+		wantErr  bool
+	}{
+		{name: "registered", gateway: "test-gw-a", template: "sofia/gateway/{{.Gateway}}/{{.Destination}}", want: "sofia/gateway/test-gw-a/1900"},
+		{name: "explicit wins", gateway: "test-gw-b", template: "sofia/gateway/{{.Gateway}}/{{.Destination}}", text: "loopback/{{.Destination}}", want: "loopback/1900"},
+		{name: "invalid", gateway: "test-gw-c", template: "sofia/{{.Gateway", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				dialTemplatesMu.Lock()
+				delete(dialTemplates, tt.gateway)
+				dialTemplatesMu.Unlock()
+			})
+
+			err := RegisterDialTemplate(tt.gateway, tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterDialTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := DialString(tt.text, DialTarget{Destination: "1900", Gateway: tt.gateway, Profile: "external"})
+			if err != nil {
+				t.Fatalf("DialString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DialString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	got, err := DialString("", DialTarget{Destination: "1900", Gateway: "test-gw-unregistered", Profile: "internal"})
+	if err != nil || got != "sofia/internal/1900@test-gw-unregistered" {
+		t.Errorf("unregistered gateway = %q, %v, want the default template", got, err)
+	}
+}
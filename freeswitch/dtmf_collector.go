@@ -0,0 +1,101 @@
+package freeswitch
+
+import (
+	"context"
+	"fmt"
+	error2 "github.com/luongdev/fsflow/errors"
+	"strings"
+	"time"
+)
+
+// DtmfCollector gathers the DTMF digits pressed on a session from the event
+// stream, without occupying the channel the way play_and_get_digits does.
+type DtmfCollector struct {
+	client     SocketClient
+	sessionId  string
+	terminator string
+	timeout    time.Duration
+
+	OnDigit func(digit string)
+}
+
+func NewDtmfCollector(client SocketClient, sessionId, terminator string, timeout time.Duration) *DtmfCollector {
+	return &DtmfCollector{client: client, sessionId: sessionId, terminator: terminator, timeout: timeout}
+}
+
+// Collect buffers digits until the terminator is pressed or no digit arrives
+// within the timeout, and returns them without the terminator, in the order
+// FreeSWITCH numbered their events.
+func (c *DtmfCollector) Collect(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := c.client.SubscribeWithOptions(ctx, []string{"DTMF"}, SubscribeOptions{
+		Filters: map[string]string{"Unique-ID": c.sessionId},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	var digits dtmfDigits
+	for {
+		select {
+		case <-ctx.Done():
+			return digits.String(), ctx.Err()
+		case <-timer.C:
+			return digits.String(), nil
+		case e, ok := <-events:
+			if !ok {
+				return digits.String(), error2.NewConnectionLostError(fmt.Errorf("event subscription closed"))
+			}
+
+			digit := e.GetHeader("DTMF-Digit")
+			if digit == "" {
+				continue
+			}
+
+			if c.OnDigit != nil {
+				c.OnDigit(digit)
+			}
+
+			if c.terminator != "" && digit == c.terminator {
+				return digits.String(), nil
+			}
+
+			digits.add(eventSequence(e), digit)
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(c.timeout)
+		}
+	}
+}
+
+type dtmfDigit struct {
+	seq   uint64
+	digit string
+}
+
+// dtmfDigits keeps digits sorted by Event-Sequence, should one still arrive
+// after a digit pressed later.
+type dtmfDigits []dtmfDigit
+
+func (d *dtmfDigits) add(seq uint64, digit string) {
+	*d = append(*d, dtmfDigit{seq: seq, digit: digit})
+	for i := len(*d) - 1; seq > 0 && i > 0 && (*d)[i-1].seq > seq; i-- {
+		(*d)[i-1], (*d)[i] = (*d)[i], (*d)[i-1]
+	}
+}
+
+func (d dtmfDigits) String() string {
+	var b strings.Builder
+	for _, digit := range d {
+		b.WriteString(digit.digit)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package freeswitch
+
+import (
+	"testing"
+)
+
+func TestDtmfDigits(t *testing.T) {
+	tests := []struct {
+		name   string
+		seqs   []uint64
+		digits []string
+		want   string
+	}{
+		{name: "in order", seqs: []uint64{1, 2, 3}, digits: []string{"1", "2", "3"}, want: "123"},
+		{name: "reordered", seqs: []uint64{2, 1, 3}, digits: []string{"2", "1", "3"}, want: "123"},
+		{name: "late first digit", seqs: []uint64{12, 13, 11}, digits: []string{"2", "3", "1"}, want: "123"},
+		{name: "without sequence", seqs: []uint64{0, 0}, digits: []string{"9", "#"}, want: "9#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d dtmfDigits
+			for i := range tt.seqs {
+				d.add(tt.seqs[i], tt.digits[i])
+			}
+			if got := d.String(); got != tt.want {
+				t.Errorf("digits = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
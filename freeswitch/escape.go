@@ -0,0 +1,54 @@
+package freeswitch
+
+import (
+	"regexp"
+	"strings"
+)
+
+var argReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// EscapeArg makes a single value safe to place on an ESL command line. Line
+// breaks would terminate the command early so they are folded into spaces,
+// values containing blanks or quotes are wrapped in single quotes.
+func EscapeArg(s string) string {
+	s = argReplacer.Replace(s)
+	if s == "" {
+		return "''"
+	}
+
+	if !strings.ContainsAny(s, " \t'\"\\") {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, "\\", "\\\\"), "'", "\\'") + "'"
+}
+
+func (c *Command) WithArgs(args ...string) *Command {
+	escaped := make([]string, len(args))
+	for i, a := range args {
+		escaped[i] = EscapeArg(a)
+	}
+	c.AppArgs = strings.Join(escaped, " ")
+
+	return c
+}
+
+var varReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ", "\\", "\\\\", "'", "\\'", ",", "\\,")
+
+var varNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// EscapeVar makes a value safe inside an originate {var=val,...} block, where
+// an unescaped comma would start the next variable.
+func EscapeVar(s string) string {
+	return varReplacer.Replace(s)
+}
+
+func IsValidVarName(name string) bool {
+	return varNamePattern.MatchString(name)
+}
+
+// EscapeHeader folds line breaks in a value sent as an ESL header, such as the
+// arguments of an executed application, which are passed on verbatim.
+func EscapeHeader(s string) string {
+	return argReplacer.Replace(s)
+}
@@ -0,0 +1,56 @@
+package freeswitch
+
+import (
+	"testing"
+)
+
+func TestEscapeArg(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{name: "plain", arg: "/tmp/greeting.wav", want: "/tmp/greeting.wav"},
+		{name: "empty", arg: "", want: "''"},
+		{name: "space", arg: "/tmp/my greeting.wav", want: "'/tmp/my greeting.wav'"},
+		{name: "tab", arg: "a\tb", want: "'a\tb'"},
+		{name: "single quote", arg: "it's", want: `'it\'s'`},
+		{name: "double quote", arg: `say "hi"`, want: `'say "hi"'`},
+		{name: "backslash", arg: `C:\tmp`, want: `'C:\\tmp'`},
+		{name: "newline", arg: "a\nb", want: "'a b'"},
+		{name: "crlf", arg: "a\r\nb", want: "'a b'"},
+		{name: "injected command", arg: "x\n\napi hupall", want: "'x  api hupall'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeArg(tt.arg); got != tt.want {
+				t.Errorf("EscapeArg(%q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandWithArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "none", want: ""},
+		{name: "plain", args: []string{"start", "/tmp/a.wav"}, want: "start /tmp/a.wav"},
+		{name: "space", args: []string{"start", "/tmp/a b.wav"}, want: "start '/tmp/a b.wav'"},
+		{name: "quotes", args: []string{`"quoted"`, "it's"}, want: `'"quoted"' 'it\'s'`},
+		{name: "newline", args: []string{"a\nb", "c"}, want: "'a b' c"},
+		{name: "empty arg", args: []string{"", "c"}, want: "'' c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := (&Command{AppName: "uuid_record"}).WithArgs(tt.args...)
+			if cmd.AppArgs != tt.want {
+				t.Errorf("WithArgs(%q) = %q, want %q", tt.args, cmd.AppArgs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package freeswitch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/percipia/eslgo"
+	"io"
+	"net/textproto"
+	"net/url"
+	"strconv"
+)
+
+type EventFormat string
+
+const (
+	EventFormatPlain EventFormat = "plain"
+	EventFormatJSON  EventFormat = "json"
+)
+
+// DecodeEvent parses the body of an ESL event in either plain or json format.
+// eslgo leaves json events empty, so subscribers wanting that format decode
+// the raw payload here.
+func DecodeEvent(format EventFormat, data []byte) (*Event, error) {
+	var e *eslgo.Event
+	var err error
+
+	switch format {
+	case EventFormatPlain, "":
+		e, err = decodePlainEvent(data)
+	case EventFormatJSON:
+		e, err = decodeJSONEvent(data)
+	default:
+		return nil, fmt.Errorf("unsupported event format '%v'", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEvent(nil, e), nil
+}
+
+func decodePlainEvent(data []byte) (*eslgo.Event, error) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("cannot decode plain event: %w", err)
+	}
+
+	e := &eslgo.Event{Headers: headers}
+	if length := headers.Get("Content-Length"); length != "" {
+		n, err := strconv.Atoi(length)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event Content-Length '%v'", length)
+		}
+
+		e.Body = make([]byte, n)
+		if _, err = io.ReadFull(reader, e.Body); err != nil {
+			return nil, fmt.Errorf("cannot read event body: %w", err)
+		}
+	}
+
+	return e, nil
+}
+
+func decodeJSONEvent(data []byte) (*eslgo.Event, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("cannot decode json event: %w", err)
+	}
+
+	// GetHeader unescapes values, as plain events arrive url encoded.
+	e := &eslgo.Event{Headers: textproto.MIMEHeader{}}
+	for k, v := range fields {
+		if k == "_body" {
+			e.Body = []byte(fmt.Sprintf("%v", v))
+			continue
+		}
+
+		if values, ok := v.([]interface{}); ok {
+			for _, value := range values {
+				e.Headers.Add(k, url.PathEscape(fmt.Sprintf("%v", value)))
+			}
+			continue
+		}
+
+		e.Headers.Set(k, url.PathEscape(fmt.Sprintf("%v", v)))
+	}
+
+	return e, nil
+}
+
+func (e *Event) Name() string {
+	return e.GetName()
+}
+
+func (e *Event) UniqueID() string {
+	return e.GetHeader("Unique-ID")
+}
+
+func (e *Event) Get(header string) string {
+	return e.GetHeader(header)
+}
+
+// HeaderMap flattens the event headers, keeping the first value of each.
+func (e *Event) HeaderMap() map[string]string {
+	headers := make(map[string]string, len(e.Headers))
+	for k := range e.Headers {
+		headers[k] = e.GetHeader(k)
+	}
+
+	return headers
+}
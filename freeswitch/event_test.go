@@ -0,0 +1,190 @@
+package freeswitch
+
+import (
+	"testing"
+)
+
+// Payloads as FreeSWITCH sends them for "event plain" and "event json".
+const (
+	plainAnswerEvent = "Event-Name: CHANNEL_ANSWER\n" +
+		"Core-UUID: 2f3c4f1e-1b1d-4f6a-8a51-3d7e7c6b9a10\n" +
+		"Event-Date-Local: 2024-05-01%2010%3A00%3A00\n" +
+		"Unique-ID: 6a1e0c52-8d0b-4b7e-a3f4-2b8c9d0e1f23\n" +
+		"Channel-Call-UUID: 6a1e0c52-8d0b-4b7e-a3f4-2b8c9d0e1f23\n" +
+		"Caller-Caller-ID-Name: Nguyen%20Van%20A\n" +
+		"Answer-State: answered\n" +
+		"variable_domain: sip.example.com\n" +
+		"variable_session_id: 0b9f6c1d-3e2a-4c5b-8d7e-6f1a2b3c4d5e\n" +
+		"\n"
+
+	plainBackgroundJobEvent = "Event-Name: BACKGROUND_JOB\n" +
+		"Job-UUID: 7f4db78a-17d7-11dd-b7a0-db4edd065621\n" +
+		"Job-Command: originate\n" +
+		"Content-Length: 41\n" +
+		"\n" +
+		"+OK 7f4de4bc-17d7-11dd-b7a0-db4edd065621\n"
+
+	jsonAnswerEvent = `{
+		"Event-Name": "CHANNEL_ANSWER",
+		"Core-UUID": "2f3c4f1e-1b1d-4f6a-8a51-3d7e7c6b9a10",
+		"Event-Date-Local": "2024-05-01 10:00:00",
+		"Unique-ID": "6a1e0c52-8d0b-4b7e-a3f4-2b8c9d0e1f23",
+		"Channel-Call-UUID": "6a1e0c52-8d0b-4b7e-a3f4-2b8c9d0e1f23",
+		"Caller-Caller-ID-Name": "Nguyen Van A",
+		"Answer-State": "answered",
+		"variable_domain": "sip.example.com",
+		"variable_session_id": "0b9f6c1d-3e2a-4c5b-8d7e-6f1a2b3c4d5e"
+	}`
+
+	jsonBackgroundJobEvent = `{
+		"Event-Name": "BACKGROUND_JOB",
+		"Job-UUID": "7f4db78a-17d7-11dd-b7a0-db4edd065621",
+		"Job-Command": "originate",
+		"Content-Length": "41",
+		"_body": "+OK 7f4de4bc-17d7-11dd-b7a0-db4edd065621\n"
+	}`
+
+	jsonMultiValueEvent = `{
+		"Event-Name": "CUSTOM",
+		"Event-Subclass": "sofia::register",
+		"Via": ["SIP/2.0/UDP 10.0.0.1:5060", "SIP/2.0/UDP 10.0.0.2:5060"]
+	}`
+)
+
+func TestDecodeEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  EventFormat
+		data    string
+		headers map[string]string
+		body    string
+	}{
+		{
+			name:   "plain answer",
+			format: EventFormatPlain,
+			data:   plainAnswerEvent,
+			headers: map[string]string{
+				"Event-Name":            "CHANNEL_ANSWER",
+				"Event-Date-Local":      "2024-05-01 10:00:00",
+				"Caller-Caller-ID-Name": "Nguyen Van A",
+				"Answer-State":          "answered",
+			},
+		},
+		{
+			name: "default format is plain",
+			data: plainAnswerEvent,
+			headers: map[string]string{
+				"Event-Name": "CHANNEL_ANSWER",
+			},
+		},
+		{
+			name:   "plain with body",
+			format: EventFormatPlain,
+			data:   plainBackgroundJobEvent,
+			headers: map[string]string{
+				"Event-Name":  "BACKGROUND_JOB",
+				"Job-UUID":    "7f4db78a-17d7-11dd-b7a0-db4edd065621",
+				"Job-Command": "originate",
+			},
+			body: "+OK 7f4de4bc-17d7-11dd-b7a0-db4edd065621\n",
+		},
+		{
+			name:   "json answer",
+			format: EventFormatJSON,
+			data:   jsonAnswerEvent,
+			headers: map[string]string{
+				"Event-Name":            "CHANNEL_ANSWER",
+				"Event-Date-Local":      "2024-05-01 10:00:00",
+				"Caller-Caller-ID-Name": "Nguyen Van A",
+				"Answer-State":          "answered",
+			},
+		},
+		{
+			name:   "json with body",
+			format: EventFormatJSON,
+			data:   jsonBackgroundJobEvent,
+			headers: map[string]string{
+				"Event-Name":  "BACKGROUND_JOB",
+				"Job-UUID":    "7f4db78a-17d7-11dd-b7a0-db4edd065621",
+				"Job-Command": "originate",
+			},
+			body: "+OK 7f4de4bc-17d7-11dd-b7a0-db4edd065621\n",
+		},
+		{
+			name:   "json multi-value header",
+			format: EventFormatJSON,
+			data:   jsonMultiValueEvent,
+			headers: map[string]string{
+				"Event-Name":     "CUSTOM",
+				"Event-Subclass": "sofia::register",
+				"Via":            "SIP/2.0/UDP 10.0.0.1:5060",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := DecodeEvent(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("DecodeEvent() error = %v", err)
+			}
+
+			for k, want := range tt.headers {
+				if got := e.Get(k); got != want {
+					t.Errorf("Get(%q) = %q, want %q", k, got, want)
+				}
+			}
+			if got := string(e.Body); got != tt.body {
+				t.Errorf("Body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestDecodeEventChannel(t *testing.T) {
+	for _, format := range []EventFormat{EventFormatPlain, EventFormatJSON} {
+		data := plainAnswerEvent
+		if format == EventFormatJSON {
+			data = jsonAnswerEvent
+		}
+
+		e, err := DecodeEvent(format, []byte(data))
+		if err != nil {
+			t.Fatalf("DecodeEvent(%v) error = %v", format, err)
+		}
+
+		if e.Name() != "CHANNEL_ANSWER" {
+			t.Errorf("%v: Name() = %q, want CHANNEL_ANSWER", format, e.Name())
+		}
+		if e.UniqueId != "6a1e0c52-8d0b-4b7e-a3f4-2b8c9d0e1f23" {
+			t.Errorf("%v: UniqueId = %q", format, e.UniqueId)
+		}
+		if e.SessionId != "0b9f6c1d-3e2a-4c5b-8d7e-6f1a2b3c4d5e" {
+			t.Errorf("%v: SessionId = %q", format, e.SessionId)
+		}
+		if e.Domain != "sip.example.com" {
+			t.Errorf("%v: Domain = %q", format, e.Domain)
+		}
+	}
+}
+
+func TestDecodeEventErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format EventFormat
+		data   string
+	}{
+		{name: "unsupported format", format: "xml", data: "<event/>"},
+		{name: "invalid json", format: EventFormatJSON, data: `{"Event-Name": `},
+		{name: "invalid content length", format: EventFormatPlain, data: "Event-Name: BACKGROUND_JOB\nContent-Length: abc\n\n"},
+		{name: "truncated body", format: EventFormatPlain, data: "Event-Name: BACKGROUND_JOB\nContent-Length: 41\n\n+OK\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeEvent(tt.format, []byte(tt.data)); err == nil {
+				t.Errorf("DecodeEvent() error = nil, want an error")
+			}
+		})
+	}
+}
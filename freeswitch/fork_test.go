@@ -0,0 +1,56 @@
+package freeswitch
+
+import (
+	"testing"
+)
+
+func TestForkCallURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Originator
+		want  string
+	}{
+		{
+			name:  "simultaneous",
+			input: Originator{UniqueId: "abc", Gateway: "carrier", Profile: "external", Destinations: []string{"1001", "1002"}},
+			want: "[fsflow_destination=1001,origination_uuid=abc-1]sofia/external/1001@carrier," +
+				"[fsflow_destination=1002,origination_uuid=abc-2]sofia/external/1002@carrier",
+		},
+		{
+			name:  "sequential",
+			input: Originator{UniqueId: "abc", Gateway: "carrier", Profile: "external", Destinations: []string{"1001", "1002"}, Sequential: true},
+			want: "[fsflow_destination=1001,origination_uuid=abc-1]sofia/external/1001@carrier|" +
+				"[fsflow_destination=1002,origination_uuid=abc-2]sofia/external/1002@carrier",
+		},
+		{
+			name:  "without unique id",
+			input: Originator{Gateway: "carrier", Profile: "external", Destinations: []string{"1001"}},
+			want:  "[fsflow_destination=1001]sofia/external/1001@carrier",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := forkCallURL(&tt.input)
+			if err != nil {
+				t.Fatalf("forkCallURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("forkCallURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForkLegIds(t *testing.T) {
+	got := ForkLegIds("abc", 3)
+	want := []string{"abc-1", "abc-2", "abc-3"}
+	if len(got) != len(want) {
+		t.Fatalf("ForkLegIds() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ForkLegIds()[%v] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
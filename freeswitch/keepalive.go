@@ -0,0 +1,83 @@
+package freeswitch
+
+import (
+	"context"
+	"errors"
+	error2 "github.com/luongdev/fsflow/errors"
+	"github.com/percipia/eslgo/command"
+	"log"
+	"time"
+)
+
+const DefaultKeepaliveInterval = 30 * time.Second
+
+// Ping sends a lightweight api status over the current connection. A failed
+// ping hands the connection over to the reconnection logic right away.
+func (s *SocketClientImpl) Ping(ctx context.Context) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	raw, err := conn.SendCommand(ctx, &command.API{Command: "status"})
+	if err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) && s.canReconnect() {
+			go func() {
+				_ = s.reconnect(conn)
+			}()
+		}
+
+		return error2.NewConnectionLostError(err)
+	}
+
+	if res, ok := NewResponse(raw).Get(); !ok {
+		return NewApiError("status", res)
+	}
+
+	return nil
+}
+
+// StartKeepalive pings the connection every interval until Close is called.
+// A non-positive interval disables the keepalive.
+func (s *SocketClientImpl) StartKeepalive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	if s.keepalive != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.keepalive = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := s.Ping(ctx); err != nil {
+					log.Printf("Keepalive failed: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+func (s *SocketClientImpl) stopKeepalive() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keepalive != nil {
+		close(s.keepalive)
+		s.keepalive = nil
+	}
+}
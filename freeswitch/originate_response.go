@@ -0,0 +1,40 @@
+package freeswitch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseOriginateResponse reads the reply of a foreground originate: "+OK
+// <uuid>" gives the uuid of the answered leg, "-ERR <cause>" and "-USAGE" the
+// cause of the failure along with an error. Only the first non-empty line
+// counts, FreeSWITCH sometimes trailing the reply with more.
+func ParseOriginateResponse(res string) (uuid string, cause string, err error) {
+	line := ""
+	for _, l := range strings.Split(res, "\n") {
+		if line = strings.TrimSpace(l); line != "" {
+			break
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(line, string(Success)):
+		uuid = strings.TrimSpace(strings.TrimPrefix(line, string(Success)))
+		if uuid == "" {
+			return "", "", fmt.Errorf("originate reply carries no uuid: %q", res)
+		}
+
+		return uuid, "", nil
+	case strings.HasPrefix(line, string(Failure)):
+		fields := strings.Fields(strings.TrimPrefix(line, string(Failure)))
+		if len(fields) == 0 {
+			return "", "", fmt.Errorf("originate failed without a cause: %q", res)
+		}
+
+		return "", fields[0], fmt.Errorf("failed to originate call: %v", fields[0])
+	case strings.HasPrefix(line, string(Syntax)):
+		return "", CauseUsage, fmt.Errorf("failed to originate call: %v", line)
+	default:
+		return "", "", fmt.Errorf("unexpected originate reply: %q", res)
+	}
+}
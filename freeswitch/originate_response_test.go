@@ -0,0 +1,40 @@
+package freeswitch
+
+import (
+	"testing"
+)
+
+func TestParseOriginateResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		res       string
+		wantUUID  string
+		wantCause string
+		wantErr   bool
+	}{
+		{name: "ok", res: "+OK 8c1d2a3e-6f4b-4c1a-9e2d-5b7f0a1c3d4e\n", wantUUID: "8c1d2a3e-6f4b-4c1a-9e2d-5b7f0a1c3d4e"},
+		{name: "ok without uuid", res: "+OK\n", wantErr: true},
+		{name: "err", res: "-ERR NO_ANSWER\n", wantCause: "NO_ANSWER", wantErr: true},
+		{name: "err without cause", res: "-ERR\n", wantErr: true},
+		{name: "usage", res: "-USAGE: <call url> <exten>|&<application_name>(<app_args>)\n", wantCause: CauseUsage, wantErr: true},
+		{name: "unexpected", res: "Command not found!\n", wantErr: true},
+		{name: "empty", res: "", wantErr: true},
+		{name: "multi-line ok", res: "\n+OK 8c1d2a3e-6f4b-4c1a-9e2d-5b7f0a1c3d4e\n-ERR NORMAL_CLEARING\n", wantUUID: "8c1d2a3e-6f4b-4c1a-9e2d-5b7f0a1c3d4e"},
+		{name: "multi-line err", res: "-ERR USER_BUSY\n+OK 8c1d2a3e-6f4b-4c1a-9e2d-5b7f0a1c3d4e\n", wantCause: "USER_BUSY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uuid, cause, err := ParseOriginateResponse(tt.res)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOriginateResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if uuid != tt.wantUUID {
+				t.Errorf("ParseOriginateResponse() uuid = %q, want %q", uuid, tt.wantUUID)
+			}
+			if cause != tt.wantCause {
+				t.Errorf("ParseOriginateResponse() cause = %q, want %q", cause, tt.wantCause)
+			}
+		})
+	}
+}
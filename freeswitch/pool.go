@@ -0,0 +1,137 @@
+package freeswitch
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+type poolMember struct {
+	client   *SocketClientImpl
+	inflight int
+	evicted  bool
+}
+
+// Pool spreads Api calls over several ESL connections, handing each call to
+// the least busy one. Connections failing at the transport level are evicted
+// and replaced by freshly dialed ones.
+type Pool struct {
+	mu      sync.Mutex
+	dial    Dialer
+	members []*poolMember
+	next    int
+}
+
+func NewPool(dial Dialer, size int) (*Pool, error) {
+	if size <= 0 {
+		size = 1
+	}
+
+	p := &Pool{dial: dial}
+	for i := 0; i < size; i++ {
+		conn, err := dial()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.members = append(p.members, &poolMember{client: NewSocketClient(conn)})
+	}
+
+	return p, nil
+}
+
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.members)
+}
+
+func (p *Pool) Api(ctx context.Context, cmd *Command) (string, error) {
+	i, m, err := p.acquire()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := m.client.Api(ctx, cmd)
+	p.release(m)
+
+	// A cancelled call may still get its reply later, which would desync the
+	// connection, so anything but an ApiError evicts the member.
+	var apiErr *ApiError
+	if err != nil && !errors.As(err, &apiErr) {
+		p.evict(i, m)
+	}
+
+	return res, err
+}
+
+func (p *Pool) Close() {
+	p.mu.Lock()
+	members := p.members
+	p.members = nil
+	p.mu.Unlock()
+
+	for _, m := range members {
+		_ = m.client.Close()
+	}
+}
+
+func (p *Pool) acquire() (int, *poolMember, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.members)
+	if n == 0 {
+		return 0, nil, ErrClientClosed
+	}
+
+	idx := p.next % n
+	for j := 1; j < n; j++ {
+		k := (p.next + j) % n
+		if p.members[k].inflight < p.members[idx].inflight {
+			idx = k
+		}
+	}
+
+	p.next = (idx + 1) % n
+	m := p.members[idx]
+	m.inflight++
+
+	return idx, m, nil
+}
+
+func (p *Pool) release(m *poolMember) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m.inflight--
+}
+
+func (p *Pool) evict(i int, m *poolMember) {
+	p.mu.Lock()
+	if m.evicted || i >= len(p.members) || p.members[i] != m {
+		p.mu.Unlock()
+		return
+	}
+	m.evicted = true
+	p.mu.Unlock()
+
+	m.client.close(false)
+
+	conn, err := p.dial()
+	if err != nil {
+		log.Printf("Failed to replace pooled connection %v: %v", i, err)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i < len(p.members) && p.members[i] == m {
+		p.members[i] = &poolMember{client: NewSocketClient(conn)}
+	} else {
+		conn.Close()
+	}
+}
@@ -0,0 +1,95 @@
+package freeswitch
+
+import (
+	"fmt"
+	"github.com/percipia/eslgo"
+	"log"
+	"time"
+)
+
+type ReconnectPolicy struct {
+	InitialDelay time.Duration `yaml:"initial_delay"`
+	MaxDelay     time.Duration `yaml:"max_delay"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+}
+
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	MaxAttempts:  5,
+}
+
+type Dialer func() (*eslgo.Conn, error)
+
+var idempotentCommands = map[string]bool{
+	"status":        true,
+	"show":          true,
+	"uuid_exists":   true,
+	"uuid_getvar":   true,
+	"uuid_dump":     true,
+	"global_getvar": true,
+	"version":       true,
+}
+
+func isIdempotent(appName string) bool {
+	return idempotentCommands[appName]
+}
+
+func (s *SocketClientImpl) canReconnect() bool {
+	return s.dial != nil && s.policy.MaxAttempts > 0 && !s.isClosed()
+}
+
+// reconnect replaces the broken connection with a freshly dialed one. Only the
+// first caller observing a given broken connection redials, every other caller
+// gets a ConnectionLostError until the new connection is in place.
+func (s *SocketClientImpl) reconnect(broken *eslgo.Conn) error {
+	s.mu.Lock()
+	if s.Conn != broken || s.reconnecting {
+		s.mu.Unlock()
+		return nil
+	}
+	s.reconnecting = true
+	s.mu.Unlock()
+
+	broken.Close()
+	s.disconnected()
+
+	delay := s.policy.InitialDelay
+	var err error
+	for attempt := 1; attempt <= s.policy.MaxAttempts; attempt++ {
+		var conn *eslgo.Conn
+		conn, err = s.dial()
+		if err == nil {
+			s.mu.Lock()
+			if s.closed {
+				s.reconnecting = false
+				s.mu.Unlock()
+				conn.Close()
+
+				return ErrClientClosed
+			}
+			s.Conn = conn
+			s.reconnecting = false
+			s.mu.Unlock()
+
+			s.restore(conn)
+			log.Printf("Reconnected to freeswitch after %v attempt(s)", attempt)
+
+			return nil
+		}
+
+		log.Printf("Reconnect attempt %v/%v failed: %v", attempt, s.policy.MaxAttempts, err)
+		time.Sleep(delay)
+
+		delay *= 2
+		if s.policy.MaxDelay > 0 && delay > s.policy.MaxDelay {
+			delay = s.policy.MaxDelay
+		}
+	}
+
+	s.mu.Lock()
+	s.reconnecting = false
+	s.mu.Unlock()
+
+	return fmt.Errorf("failed to reconnect after %v attempt(s): %v", s.policy.MaxAttempts, err)
+}
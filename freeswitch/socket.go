@@ -28,6 +28,14 @@ func NewFreeswitchSocket(c *Config) (SocketServer, SocketClient, error) {
 		c.Timeout = 10 * time.Second
 	}
 
+	if c.Reconnect == (ReconnectPolicy{}) {
+		c.Reconnect = DefaultReconnectPolicy
+	}
+
+	if c.Keepalive == 0 {
+		c.Keepalive = DefaultKeepaliveInterval
+	}
+
 	store := NewSocketStore()
 	server := NewSocketServer(c.ListenOn, store)
 
@@ -46,15 +54,27 @@ func NewFreeswitchSocket(c *Config) (SocketServer, SocketClient, error) {
 	}
 
 	hostPort := fmt.Sprintf("%v:%v", c.Host, c.Port)
-	conn, err := eslgo.Dial(hostPort, c.Password, func() {
-		fmt.Printf("Server %v disconnected", hostPort)
-	})
 
+	var client *SocketClientImpl
+	dial := func() (*eslgo.Conn, error) {
+		var conn *eslgo.Conn
+		conn, err := eslgo.Dial(hostPort, c.Password, func() {
+			fmt.Printf("Server %v disconnected", hostPort)
+			if client != nil && client.canReconnect() {
+				go func() {
+					_ = client.reconnect(conn)
+				}()
+			}
+		})
+
+		return conn, err
+	}
+
+	client, err := NewReconnectingSocketClient(dial, c.Reconnect)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	client := NewSocketClient(conn)
 	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
 	defer cancel()
 
@@ -67,7 +87,21 @@ func NewFreeswitchSocket(c *Config) (SocketServer, SocketClient, error) {
 		return nil, nil, err
 	}
 
-	store.Set(DefaultClient, &client)
+	if c.PoolSize > 1 {
+		pool, err := NewPool(func() (*eslgo.Conn, error) {
+			return eslgo.Dial(hostPort, c.Password, func() {})
+		}, c.PoolSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		client.UsePool(pool)
+	}
+
+	client.EnableApiCache(c.ApiCacheTTL)
+	client.SetSubscribeOptions(c.Events)
+	client.SerializeChannelCommands(c.SerializeChannels)
+	client.StartKeepalive(c.Keepalive)
+	store.Set(DefaultClient, client)
 
-	return &server, &client, nil
+	return &server, client, nil
 }
@@ -2,17 +2,24 @@ package freeswitch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	error2 "github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/tracing"
 	"github.com/percipia/eslgo"
 	"github.com/percipia/eslgo/command"
 	"github.com/percipia/eslgo/command/call"
+	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var _ SocketClient = (*SocketClientImpl)(nil)
 
+var ErrClientClosed = errors.New("client closed")
+
 type Filter struct {
 }
 
@@ -22,14 +29,272 @@ func (f *Filter) BuildMessage() string {
 
 type SocketClientImpl struct {
 	*eslgo.Conn
+
+	mu           sync.RWMutex
+	dial         Dialer
+	policy       ReconnectPolicy
+	reconnecting bool
+	closed       bool
+	keepalive    chan struct{}
+	pool         *Pool
+	cache        *apiCache
+	cmdLogger    CommandLogger
+	redactor     Redactor
+	channels     *channelLocks
+	gate         *replyGate
+
+	subscribeOptions SubscribeOptions
+	droppedEvents    atomic.Uint64
+
+	listeners     []eventListener
+	subscriptions []subscribedCommand
+	subscribers   map[*subscription]struct{}
+	jobs          map[string]*pendingJob
+}
+
+// subscribedCommand is a subscription replayed on reconnect, counted so that
+// repeated subscriptions share an entry until the last one is released.
+type subscribedCommand struct {
+	cmd  command.Command
+	refs int
+}
+
+type eventListener struct {
+	id       string
+	key      string
+	current  string
+	listener eslgo.EventListener
+}
+
+func NewSocketClient(conn *eslgo.Conn) *SocketClientImpl {
+	return &SocketClientImpl{Conn: conn}
+}
+
+func NewReconnectingSocketClient(dial Dialer, policy ReconnectPolicy) (*SocketClientImpl, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SocketClientImpl{Conn: conn, dial: dial, policy: policy}, nil
+}
+
+func (s *SocketClientImpl) conn() (*eslgo.Conn, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClientClosed
+	}
+
+	if s.reconnecting {
+		return nil, error2.NewConnectionLostError(fmt.Errorf("reconnect in progress"))
+	}
+
+	return s.Conn, nil
+}
+
+func (s *SocketClientImpl) sendCommand(ctx context.Context, cmd command.Command, retry bool) (*eslgo.RawResponse, error) {
+	msg := cmd.BuildMessage()
+	s.logCommand(msg)
+
+	raw, err := s.withConn(ctx, retry, func(ctx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error) {
+		return conn.SendCommand(ctx, cmd)
+	})
+
+	reply := ""
+	if raw != nil {
+		reply, _ = NewResponse(raw).Get()
+	}
+	s.logReply(msg, reply, err)
+
+	return raw, err
+}
+
+func (s *SocketClientImpl) withConn(ctx context.Context, retry bool, f commandFunc) (*eslgo.RawResponse, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := s.await(ctx, conn, f)
+	if err == nil || ctx.Err() != nil || !s.canReconnect() {
+		return raw, err
+	}
+
+	if rErr := s.reconnect(conn); rErr != nil || !retry {
+		return nil, error2.NewConnectionLostError(err)
+	}
+
+	conn, cErr := s.conn()
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	return s.await(ctx, conn, f)
 }
 
-func NewSocketClient(conn *eslgo.Conn) SocketClientImpl {
-	return SocketClientImpl{Conn: conn}
+type commandFunc func(ctx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error)
+
+type commandResult struct {
+	raw *eslgo.RawResponse
+	err error
+}
+
+// lateReplyTimeout bounds how long a command abandoned by its caller still
+// waits for its reply before the connection is taken as out of step.
+var lateReplyTimeout = 30 * time.Second
+
+// replyGate lets a single command at a time wait for its reply on conn.
+// Replies carry no request id, so a command whose caller gave up keeps the
+// gate until its late reply has been read and discarded; abandoned counts
+// the replies still owed that way.
+type replyGate struct {
+	conn      *eslgo.Conn
+	sem       chan struct{}
+	abandoned atomic.Int32
+}
+
+func (s *SocketClientImpl) gateFor(conn *eslgo.Conn) *replyGate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.gate == nil || s.gate.conn != conn {
+		s.gate = &replyGate{conn: conn, sem: make(chan struct{}, 1)}
+	}
+
+	return s.gate
+}
+
+// await runs f but stops waiting as soon as ctx is done, even when f is queued
+// behind another command on the connection. A command not yet sent is then
+// dropped; one already sent keeps reading under its own context so its late
+// reply is discarded instead of being handed to the next command. Only when
+// that reply does not arrive within lateReplyTimeout is the connection
+// replaced.
+func (s *SocketClientImpl) await(ctx context.Context, conn *eslgo.Conn, f commandFunc) (*eslgo.RawResponse, error) {
+	g := s.gateFor(conn)
+	select {
+	case g.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	rCtx, rCancel := context.WithCancel(context.WithoutCancel(ctx))
+	var settled atomic.Bool
+	done := make(chan commandResult, 1)
+	go func() {
+		raw, err := f(rCtx, conn)
+		rCancel()
+		<-g.sem
+
+		if settled.CompareAndSwap(false, true) {
+			done <- commandResult{raw: raw, err: err}
+			return
+		}
+
+		g.abandoned.Add(-1)
+		if err != nil && s.canReconnect() {
+			log.Printf("No reply to an abandoned command, replacing the connection: %v", err)
+			_ = s.reconnect(conn)
+		}
+	}()
+
+	select {
+	case r := <-done:
+		return s.result(r)
+	case <-ctx.Done():
+		if !settled.CompareAndSwap(false, true) {
+			return s.result(<-done)
+		}
+
+		g.abandoned.Add(1)
+		time.AfterFunc(lateReplyTimeout, rCancel)
+
+		return nil, ctx.Err()
+	}
+}
+
+func (s *SocketClientImpl) result(r commandResult) (*eslgo.RawResponse, error) {
+	if r.err != nil && s.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	return r.raw, r.err
+}
+
+func (s *SocketClientImpl) subscribe(ctx context.Context, cmd command.Command) (*eslgo.RawResponse, error) {
+	raw, err := s.sendCommand(ctx, cmd, true)
+	if err != nil {
+		return raw, err
+	}
+
+	msg := cmd.BuildMessage()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.subscriptions {
+		if s.subscriptions[i].cmd.BuildMessage() == msg {
+			s.subscriptions[i].refs++
+			return raw, nil
+		}
+	}
+	s.subscriptions = append(s.subscriptions, subscribedCommand{cmd: cmd, refs: 1})
+
+	return raw, nil
+}
+
+// unsubscribe releases a command taken by subscribe, so it is no longer
+// replayed on reconnect once nothing holds it. The events it asked for keep
+// flowing on the current connection, ESL having no way to take back a single
+// "event" command, and are left to the listeners to ignore.
+func (s *SocketClientImpl) unsubscribe(cmd command.Command) {
+	msg := cmd.BuildMessage()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.subscriptions {
+		if s.subscriptions[i].cmd.BuildMessage() == msg {
+			if s.subscriptions[i].refs--; s.subscriptions[i].refs <= 0 {
+				s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			}
+			return
+		}
+	}
+}
+
+func (s *SocketClientImpl) restore(conn *eslgo.Conn) {
+	s.mu.RLock()
+	listeners := append([]eventListener{}, s.listeners...)
+	subscriptions := append([]subscribedCommand{}, s.subscriptions...)
+	s.mu.RUnlock()
+
+	for _, l := range listeners {
+		current := conn.RegisterEventListener(l.id, l.listener)
+
+		s.mu.Lock()
+		for i := range s.listeners {
+			if s.listeners[i].key == l.key {
+				s.listeners[i].current = current
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, sub := range subscriptions {
+		if _, err := conn.SendCommand(ctx, sub.cmd); err != nil {
+			log.Printf("Failed to restore subscription '%v': %v", sub.cmd.BuildMessage(), err)
+		}
+	}
 }
 
 func (s *SocketClientImpl) AllEvents(ctx context.Context) error {
-	raw, err := s.Conn.SendCommand(ctx, &command.Event{
+	raw, err := s.subscribe(ctx, &command.Event{
 		Format: "plain",
 		Listen: []string{"ALL"},
 	})
@@ -46,7 +311,7 @@ func (s *SocketClientImpl) AllEvents(ctx context.Context) error {
 }
 
 func (s *SocketClientImpl) MyEvents(ctx context.Context, id string) error {
-	raw, err := s.Conn.SendCommand(ctx, &command.MyEvents{Format: "plain", UUID: id})
+	raw, err := s.subscribe(ctx, &command.MyEvents{Format: "plain", UUID: id})
 
 	if err != nil {
 		return err
@@ -60,7 +325,7 @@ func (s *SocketClientImpl) MyEvents(ctx context.Context, id string) error {
 }
 
 func (s *SocketClientImpl) AddFilter(ctx context.Context, header, value string) error {
-	raw, err := s.Conn.SendCommand(ctx, &command.Filter{
+	raw, err := s.subscribe(ctx, &command.Filter{
 		EventHeader: header,
 		FilterValue: value,
 		Delete:      false,
@@ -78,15 +343,24 @@ func (s *SocketClientImpl) AddFilter(ctx context.Context, header, value string)
 }
 
 func (s *SocketClientImpl) DelFilter(ctx context.Context, header, value string) error {
-	raw, err := s.Conn.SendCommand(ctx, &command.Filter{
+	raw, err := s.sendCommand(ctx, &command.Filter{
 		EventHeader: header,
 		FilterValue: value,
 		Delete:      true,
-	})
+	}, true)
 
 	if err != nil {
 		return err
 	}
+
+	s.mu.Lock()
+	for i, sub := range s.subscriptions {
+		if f, ok := sub.cmd.(*command.Filter); ok && f.EventHeader == header && f.FilterValue == value {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
 	res, ok := NewResponse(raw).Get()
 	if !ok {
 		return fmt.Errorf("failed to delete filter events: %v", res)
@@ -99,21 +373,50 @@ func (s *SocketClientImpl) EventListener(id string, listener EventListener) stri
 	if listener == nil {
 		return ""
 	}
-	return s.Conn.RegisterEventListener(id, func(event *eslgo.Event) {
+	l := func(event *eslgo.Event) {
 		listener(NewEvent(s, event))
-	})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.Conn.RegisterEventListener(id, l)
+	s.listeners = append(s.listeners, eventListener{id: id, key: key, current: key, listener: l})
+
+	return key
+}
+
+func (s *SocketClientImpl) RemoveEventListener(id, listenerId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, l := range s.listeners {
+		if l.id == id && l.key == listenerId {
+			s.Conn.RemoveEventListener(id, l.current)
+			s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+			return
+		}
+	}
 }
 
 func (s *SocketClientImpl) Execute(ctx context.Context, cmd *Command) (string, error) {
 	if cmd.Uid == "" {
 		return "", fmt.Errorf("uuid is required")
 	}
+	s.invalidateCache()
+
+	unlock, err := s.lockChannel(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
 
-	raw, err := s.Conn.SendCommand(ctx, &call.Execute{
+	raw, err := s.sendCommand(ctx, &call.Execute{
 		UUID:    cmd.Uid,
 		AppName: cmd.AppName,
 		AppArgs: cmd.AppArgs,
-	})
+		Loops:   cmd.Loops,
+	}, false)
 
 	if err != nil {
 		return "", err
@@ -127,38 +430,102 @@ func (s *SocketClientImpl) Execute(ctx context.Context, cmd *Command) (string, e
 	return res, nil
 }
 
-func (s *SocketClientImpl) Api(ctx context.Context, cmd *Command) (string, error) {
-	raw, err := s.Conn.SendCommand(ctx, &command.API{Command: cmd.AppName, Arguments: cmd.AppArgs})
+func (s *SocketClientImpl) Api(ctx context.Context, cmd *Command) (res string, err error) {
+	ctx, span := tracing.Start(ctx, "freeswitch.api")
+	span.SetTag("command", cmd.AppName)
+	defer func() {
+		span.SetTag("status", apiStatus(err))
+		span.Finish()
+	}()
+
+	cache := s.apiCache()
+	if cache != nil {
+		if !isIdempotent(cmd.AppName) {
+			cache.clear()
+		} else if res, ok := cache.get(cmd); ok && !cmd.NoCache {
+			span.SetTag("cached", true)
+			return res, nil
+		}
+	}
+
+	unlock, err := s.lockChannel(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
-	res, ok := NewResponse(raw).Get()
-	if !ok {
-		return res, fmt.Errorf("failed to execute api '%v': %v", cmd.AppName, res)
+	defer unlock()
+
+	res, err = s.api(ctx, cmd)
+	if err == nil && cache != nil && isIdempotent(cmd.AppName) {
+		cache.put(cmd, res)
 	}
 
-	return res, nil
+	return res, err
 }
 
-func (s *SocketClientImpl) BgApi(ctx context.Context, cmd *Command) (string, error) {
-	raw, err := s.Conn.SendCommand(ctx, &command.API{Command: cmd.AppName, Arguments: cmd.AppArgs, Background: true})
+func (s *SocketClientImpl) api(ctx context.Context, cmd *Command) (string, error) {
+	ctx, cancel := commandContext(ctx, cmd)
+	defer cancel()
+
+	if s.pool != nil {
+		msg := fmt.Sprintf("api %v %v", cmd.AppName, cmd.AppArgs)
+		s.logCommand(msg)
+		res, err := s.pool.Api(ctx, cmd)
+		s.logReply(msg, res, err)
+
+		return res, err
+	}
+
+	raw, err := s.sendCommand(ctx, &command.API{Command: cmd.AppName, Arguments: cmd.AppArgs}, isIdempotent(cmd.AppName))
 	if err != nil {
 		return "", err
 	}
 	res, ok := NewResponse(raw).Get()
 	if !ok {
-		return res, fmt.Errorf("failed to execute api '%v': %v", cmd.AppName, res)
+		return res, NewApiError(cmd.AppName, res)
 	}
 
 	return res, nil
 }
 
+// originateReplyMargin is how long past the dial timeout Originate waits for
+// FreeSWITCH to reply.
+const originateReplyMargin = 5 * time.Second
+
+// commandContext bounds a command by its own Timeout when set, while the
+// caller can still cancel it. Without one the caller's deadline applies, or
+// DefaultCommandTimeout when there is none.
+func commandContext(ctx context.Context, cmd *Command) (context.Context, context.CancelFunc) {
+	if cmd.Timeout > 0 {
+		return context.WithTimeout(ctx, cmd.Timeout)
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, DefaultCommandTimeout)
+}
+
+func apiStatus(err error) string {
+	if err == nil {
+		return string(Success)
+	}
+
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		return string(Failure)
+	}
+
+	return "error"
+}
+
 func (s *SocketClientImpl) Originate(ctx context.Context, input *Originator) (string, error) {
-	if input.Gateway == "" {
+	if input.Gateway == "" && input.Endpoint == "" {
 		return "", error2.RequireField("gateway")
 	}
+	s.invalidateCache()
 
-	if input.DNIS == "" {
+	if input.DNIS == "" && len(input.Destinations) == 0 && input.Endpoint == "" {
 		return "", error2.RequireField("DNIS")
 	}
 
@@ -180,7 +547,7 @@ func (s *SocketClientImpl) Originate(ctx context.Context, input *Originator) (st
 
 	timeoutMillis := int32(input.Timeout / time.Millisecond)
 	input.Variables["sip_contact_user"] = input.ANI
-	input.Variables["originate_timeout"] = string(timeoutMillis)
+	input.Variables["originate_timeout"] = TimeoutSeconds(input.Timeout)
 	input.Variables["origination_caller_id_name"] = input.ANI
 	input.Variables["origination_caller_id_number"] = input.ANI
 
@@ -190,7 +557,7 @@ func (s *SocketClientImpl) Originate(ctx context.Context, input *Originator) (st
 	input.Variables["disable_q850_reason"] = true
 	input.Variables["origination_callback"] = input.Callback
 
-	input.Variables["Direction"] = string(input.Direction)
+	input.Variables["Direction"] = input.Direction.String()
 
 	if input.AutoAnswer {
 		input.Variables["X-Answer"] = "auto"
@@ -202,7 +569,11 @@ func (s *SocketClientImpl) Originate(ctx context.Context, input *Originator) (st
 	} else {
 		input.Variables["X-Reject"] = "deny"
 	}
-	input.Variables["X-Direction"] = string(input.Direction)
+	input.Variables["X-Direction"] = input.Direction.String()
+	if input.Direction == Outbound && input.Domain != "" {
+		input.Variables["sip_invite_domain"] = input.Domain
+	}
+
 	var bleg eslgo.Leg
 
 	if input.Extension != "" {
@@ -213,34 +584,157 @@ func (s *SocketClientImpl) Originate(ctx context.Context, input *Originator) (st
 
 	vars := make(map[string]string)
 	for k, v := range input.Variables {
+		if !IsValidVarName(k) {
+			return "", error2.NewWorkflowInputError(fmt.Sprintf("invalid channel variable name '%v'", k))
+		}
 		if strings.HasPrefix(k, "X-") {
 			k = "sip_h_" + k
 		}
-		vars[k] = fmt.Sprintf("%v", v)
+		vars[k] = EscapeVar(fmt.Sprintf("%v", v))
+	}
+
+	if input.Endpoint != "" && input.AutoAnswer {
+		for k, v := range autoAnswerVariables(input.AutoAnswerVendor) {
+			vars[k] = v
+		}
 	}
 
-	aleg := eslgo.Leg{CallURL: fmt.Sprintf("sofia/%v/%v@%v", input.Profile, input.DNIS, input.Gateway)}
-	raw, err := s.Conn.OriginateCall(ctx, input.Background, aleg, bleg, vars)
+	aleg := eslgo.Leg{}
+	if input.Endpoint != "" {
+		aleg.CallURL = input.Endpoint
+		if input.UniqueId != "" {
+			aleg.LegVariables = map[string]string{"origination_uuid": input.UniqueId}
+		}
+	} else if len(input.Destinations) > 0 {
+		url, err := forkCallURL(input)
+		if err != nil {
+			return "", error2.NewWorkflowInputError(err.Error())
+		}
+		aleg.CallURL = url
+	} else {
+		url, err := DialString(input.DialTemplate, DialTarget{Destination: input.DNIS, Gateway: input.Gateway, Profile: input.Profile})
+		if err != nil {
+			return "", error2.NewWorkflowInputError(err.Error())
+		}
+		aleg.CallURL = url
+		if input.UniqueId != "" {
+			aleg.LegVariables = map[string]string{"origination_uuid": input.UniqueId}
+		}
+	}
+	// originate only replies once the call is answered or the dial timed out.
+	ctx, cancel := commandContext(ctx, &Command{AppName: "originate", Timeout: input.Timeout + originateReplyMargin})
+	defer cancel()
+
+	raw, err := s.sendCommand(ctx, &command.API{
+		Command:    "originate",
+		Arguments:  fmt.Sprintf("%v%v %v", eslgo.BuildVars("{%s}", vars), aleg.String(), bleg.String()),
+		Background: input.Background,
+	}, false)
 	if err != nil {
 		return "", err
 	}
 
+	if !input.Background {
+		return NewResponse(raw).Text(), nil
+	}
+
 	res, ok := NewResponse(raw).Get()
 	if !ok {
-		return res, fmt.Errorf("failed to originate call: %v", res)
+		return "", NewApiError("originate", res)
 	}
 
 	return res, nil
 }
 
+// UsePool routes Api calls through the pool, other commands keep using the
+// client's own connection since they depend on its event subscriptions.
+func (s *SocketClientImpl) UsePool(pool *Pool) {
+	s.pool = pool
+}
+
+// ForkLegIds are the uuids the legs of a forked originate of uid get, one per
+// destination in order, so each can be checked or killed on its own.
+func ForkLegIds(uid string, n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%v-%v", uid, i+1)
+	}
+
+	return ids
+}
+
+// forkCallURL dials every destination at once, or one after the other when
+// Sequential, tagging each leg with the destination it rings and, given a
+// UniqueId, the uuid ForkLegIds assigns it.
+func forkCallURL(input *Originator) (string, error) {
+	sep := ","
+	if input.Sequential {
+		sep = "|"
+	}
+
+	ids := ForkLegIds(input.UniqueId, len(input.Destinations))
+	legs := make([]string, len(input.Destinations))
+	for i, d := range input.Destinations {
+		url, err := DialString(input.DialTemplate, DialTarget{Destination: d, Gateway: input.Gateway, Profile: input.Profile})
+		if err != nil {
+			return "", err
+		}
+
+		vars := fmt.Sprintf("%v=%v", DestinationVariable, EscapeVar(d))
+		if input.UniqueId != "" {
+			vars = fmt.Sprintf("%v,origination_uuid=%v", vars, EscapeVar(ids[i]))
+		}
+		legs[i] = fmt.Sprintf("[%v]%v", vars, url)
+	}
+
+	return strings.Join(legs, sep), nil
+}
+
+// Close sends the ESL exit command before dropping the connection. It is safe
+// to call more than once; calls still in flight fail with ErrClientClosed.
+func (s *SocketClientImpl) Close() error {
+	s.close(true)
+	return nil
+}
+
+func (s *SocketClientImpl) close(graceful bool) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	conn := s.Conn
+	s.mu.Unlock()
+
+	if s.pool != nil {
+		s.pool.Close()
+	}
+	s.stopKeepalive()
+	s.disconnected()
+
+	if graceful {
+		conn.ExitAndClose()
+	} else {
+		conn.Close()
+	}
+}
+
+func (s *SocketClientImpl) isClosed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.closed
+}
+
 func (s *SocketClientImpl) SendEvent(ctx context.Context, cmd *Command) (string, error) {
-	raw, err := s.Conn.SendCommand(ctx, &command.SendEvent{
+	raw, err := s.sendCommand(ctx, &command.SendEvent{
 		Name: "CUSTOM",
 		Headers: map[string][]string{
 			"Event-Subclass": {"callmanager::event"},
 			"Session-Id":     {cmd.Uid},
 		},
-	})
+	}, false)
 
 	if err != nil {
 		return "", err
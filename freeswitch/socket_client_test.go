@@ -0,0 +1,137 @@
+package freeswitch
+
+import (
+	"context"
+	"github.com/percipia/eslgo"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func reply(body string) *eslgo.RawResponse {
+	return &eslgo.RawResponse{Body: []byte(body)}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAwaitDiscardsLateReply(t *testing.T) {
+	s := &SocketClientImpl{}
+	release := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.await(ctx, nil, func(ctx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error) {
+			<-release
+			return reply("first"), nil
+		})
+		first <- err
+	}()
+
+	waitFor(t, func() bool { return len(s.gateFor(nil).sem) == 1 })
+	cancel()
+	if err := <-first; err != context.Canceled {
+		t.Fatalf("expected the first command to be cancelled, got %v", err)
+	}
+
+	g := s.gateFor(nil)
+	if n := g.abandoned.Load(); n != 1 {
+		t.Fatalf("expected 1 abandoned reply, got %v", n)
+	}
+
+	var sent atomic.Bool
+	second := make(chan *eslgo.RawResponse, 1)
+	go func() {
+		raw, _ := s.await(context.Background(), nil, func(ctx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error) {
+			sent.Store(true)
+			return reply("second"), nil
+		})
+		second <- raw
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if sent.Load() {
+		t.Fatal("second command sent before the late reply was discarded")
+	}
+
+	close(release)
+	if raw := <-second; string(raw.Body) != "second" {
+		t.Fatalf("expected the second reply, got %q", raw.Body)
+	}
+	waitFor(t, func() bool { return g.abandoned.Load() == 0 })
+}
+
+func TestAwaitDropsUnsentCommand(t *testing.T) {
+	s := &SocketClientImpl{}
+	release := make(chan struct{})
+	defer close(release)
+
+	go func() {
+		_, _ = s.await(context.Background(), nil, func(ctx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error) {
+			<-release
+			return reply("first"), nil
+		})
+	}()
+	waitFor(t, func() bool { return len(s.gateFor(nil).sem) == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var sent atomic.Bool
+	_, err := s.await(ctx, nil, func(ctx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error) {
+		sent.Store(true)
+		return reply("second"), nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if sent.Load() {
+		t.Fatal("queued command sent after its caller gave up")
+	}
+	if n := s.gateFor(nil).abandoned.Load(); n != 0 {
+		t.Fatalf("expected no abandoned reply for an unsent command, got %v", n)
+	}
+}
+
+func TestAwaitGivesUpOnMissingReply(t *testing.T) {
+	defer func(d time.Duration) { lateReplyTimeout = d }(lateReplyTimeout)
+	lateReplyTimeout = 10 * time.Millisecond
+
+	s := &SocketClientImpl{}
+	var abandoned context.Context
+	started := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.await(ctx, nil, func(rCtx context.Context, conn *eslgo.Conn) (*eslgo.RawResponse, error) {
+			abandoned = rCtx
+			close(started)
+			<-rCtx.Done()
+			return nil, rCtx.Err()
+		})
+		result <- err
+	}()
+
+	<-started
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Fatalf("expected the command to be cancelled, got %v", err)
+	}
+
+	g := s.gateFor(nil)
+	waitFor(t, func() bool { return g.abandoned.Load() == 0 && len(g.sem) == 0 })
+	if abandoned.Err() == nil {
+		t.Fatal("expected the reply wait to be cut off")
+	}
+}
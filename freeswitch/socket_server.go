@@ -10,7 +10,7 @@ import (
 var _ SocketServer = (*SocketServerImpl)(nil)
 
 type SocketServerImpl struct {
-	port               uint16
+	addr               string
 	serverEventHandler ServerEventHandler
 	sessionClosed      func(sid string)
 	store              SocketStore
@@ -27,8 +27,12 @@ func (s *SocketServerImpl) OnSessionClosed(f func(sid string)) {
 }
 
 func NewSocketServer(port uint16, store SocketStore) SocketServerImpl {
+	return NewSocketServerOn(fmt.Sprintf("0.0.0.0:%v", port), store)
+}
+
+func NewSocketServerOn(addr string, store SocketStore) SocketServerImpl {
 	return SocketServerImpl{
-		port:  port,
+		addr:  addr,
 		store: store,
 	}
 }
@@ -40,10 +44,9 @@ func (s *SocketServerImpl) SetEventHandler(handler ServerEventHandler) {
 }
 
 func (s *SocketServerImpl) ListenAndServe() error {
-	listenAddr := fmt.Sprintf("0.0.0.0:%v", s.port)
-	err := eslgo.ListenAndServe(listenAddr, func(ctx context.Context, conn *eslgo.Conn, connectResponse *eslgo.RawResponse) {
+	err := eslgo.ListenAndServe(s.addr, func(ctx context.Context, conn *eslgo.Conn, connectResponse *eslgo.RawResponse) {
 		client := NewSocketClient(conn)
-		req := NewRequest(&client, connectResponse)
+		req := NewRequest(client, connectResponse)
 		_, _ = client.Execute(ctx, &Command{
 			AppName: "multiset",
 			Uid:     req.UniqueId,
@@ -59,7 +62,7 @@ func (s *SocketServerImpl) ListenAndServe() error {
 			log.Printf("Answered call %v: %v", req.UniqueId, res)
 		}()
 
-		s.store.Set(req.UniqueId, &client)
+		s.store.Set(req.UniqueId, client)
 		if s.serverEventHandler != nil {
 			go s.serverEventHandler.OnSession(ctx, req)
 		}
@@ -68,6 +71,10 @@ func (s *SocketServerImpl) ListenAndServe() error {
 		client.AddFilter(ctx, "variable_session_id", req.UniqueId)
 
 		client.EventListener("ALL", func(event *Event) {
+			if s.serverEventHandler == nil {
+				return
+			}
+
 			if event.SessionId != "" {
 				if event.UniqueId == event.SessionId {
 					go s.serverEventHandler.OnAlegEvent(ctx, event)
@@ -86,6 +93,7 @@ func (s *SocketServerImpl) ListenAndServe() error {
 
 		select {
 		case <-ctx.Done():
+			client.disconnected()
 			if s.sessionClosed != nil {
 				s.sessionClosed(req.UniqueId)
 			}
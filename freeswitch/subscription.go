@@ -0,0 +1,259 @@
+package freeswitch
+
+import (
+	"context"
+	"fmt"
+	"github.com/percipia/eslgo"
+	"github.com/percipia/eslgo/command"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+)
+
+type SubscribeOptions struct {
+	BufferSize int            `yaml:"buffer_size"`
+	Overflow   OverflowPolicy `yaml:"overflow"`
+	// Filters narrow the subscription to events carrying these header values,
+	// e.g. Unique-ID. They are matched here rather than set as ESL filters,
+	// which would apply to the whole connection and starve other listeners.
+	Filters map[string]string `yaml:"-"`
+}
+
+var DefaultSubscribeOptions = SubscribeOptions{BufferSize: 64, Overflow: OverflowBlock}
+
+// subscription queues the events of one subscriber and hands them over from a
+// single dispatcher goroutine. eslgo calls every listener on a goroutine of
+// its own, so events reach send in no particular order and nothing here can
+// hold up the ESL read loop: the queue restores FreeSWITCH's order using
+// Event-Sequence and applies the overflow policy in that order.
+type subscription struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	once    sync.Once
+	closed  bool
+	queue   []*Event
+	size    int
+	events  chan *Event
+	done    chan struct{}
+	policy  OverflowPolicy
+	dropped *atomic.Uint64
+}
+
+func newSubscription(opts SubscribeOptions, dropped *atomic.Uint64) *subscription {
+	sub := &subscription{
+		queue:   make([]*Event, 0, opts.BufferSize),
+		size:    opts.BufferSize,
+		events:  make(chan *Event),
+		done:    make(chan struct{}),
+		policy:  opts.Overflow,
+		dropped: dropped,
+	}
+	sub.cond = sync.NewCond(&sub.mu)
+
+	go sub.dispatch()
+
+	return sub
+}
+
+// send queues e according to the overflow policy once the queue is full.
+// Blocking parks the calling eslgo goroutine until the consumer makes room, so
+// a stalled consumer costs one goroutine per pending event; the drop policies
+// bound that.
+func (sub *subscription) send(e *Event) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	for !sub.closed && len(sub.queue) >= sub.size {
+		switch sub.policy {
+		case OverflowDropNewest:
+			sub.dropped.Add(1)
+			return
+		case OverflowDropOldest:
+			sub.queue = sub.queue[1:]
+			sub.dropped.Add(1)
+		default:
+			sub.cond.Wait()
+		}
+	}
+
+	if sub.closed {
+		return
+	}
+
+	sub.queue = append(sub.queue, e)
+
+	seq := eventSequence(e)
+	for i := len(sub.queue) - 1; seq > 0 && i > 0 && eventSequence(sub.queue[i-1]) > seq; i-- {
+		sub.queue[i-1], sub.queue[i] = sub.queue[i], sub.queue[i-1]
+	}
+
+	sub.cond.Broadcast()
+}
+
+// dispatch delivers the queued events one by one until the subscription is
+// closed, then closes the channel it alone sends on.
+func (sub *subscription) dispatch() {
+	defer close(sub.events)
+
+	for {
+		sub.mu.Lock()
+		for !sub.closed && len(sub.queue) == 0 {
+			sub.cond.Wait()
+		}
+
+		if sub.closed {
+			sub.mu.Unlock()
+			return
+		}
+
+		e := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		sub.cond.Broadcast()
+		sub.mu.Unlock()
+
+		select {
+		case sub.events <- e:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+func (sub *subscription) close() {
+	sub.once.Do(func() {
+		close(sub.done)
+
+		sub.mu.Lock()
+		defer sub.mu.Unlock()
+		sub.closed = true
+		sub.queue = nil
+		sub.cond.Broadcast()
+	})
+}
+
+// eventSequence is the Event-Sequence FreeSWITCH numbers events with, 0 when
+// missing so such events keep their arrival order.
+func eventSequence(e *Event) uint64 {
+	seq, _ := strconv.ParseUint(e.GetHeader("Event-Sequence"), 10, 64)
+	return seq
+}
+
+func (s *SocketClientImpl) Subscribe(ctx context.Context, eventNames []string) (<-chan *Event, error) {
+	s.mu.RLock()
+	opts := s.subscribeOptions
+	s.mu.RUnlock()
+
+	return s.SubscribeWithOptions(ctx, eventNames, opts)
+}
+
+func (s *SocketClientImpl) SubscribeWithOptions(ctx context.Context, eventNames []string, opts SubscribeOptions) (<-chan *Event, error) {
+	opts = opts.withDefaults()
+
+	if len(eventNames) == 0 {
+		eventNames = []string{eslgo.EventListenAll}
+	}
+
+	cmd := &command.Event{Format: "plain", Listen: eventNames}
+	raw, err := s.subscribe(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if res, ok := NewResponse(raw).Get(); !ok {
+		s.unsubscribe(cmd)
+		return nil, fmt.Errorf("failed to subscribe to events %v: %v", eventNames, res)
+	}
+
+	names := make(map[string]bool, len(eventNames))
+	for _, n := range eventNames {
+		names[n] = true
+	}
+
+	sub := newSubscription(opts, &s.droppedEvents)
+	lid := s.EventListener(eslgo.EventListenAll, func(e *Event) {
+		if !names[eslgo.EventListenAll] && !names[e.GetName()] {
+			return
+		}
+
+		for h, v := range opts.Filters {
+			if e.GetHeader(h) != v {
+				return
+			}
+		}
+
+		sub.send(e)
+	})
+
+	s.mu.Lock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[*subscription]struct{})
+	}
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sub.done:
+		}
+
+		s.RemoveEventListener(eslgo.EventListenAll, lid)
+		s.unsubscribe(cmd)
+
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+
+		sub.close()
+	}()
+
+	return sub.events, nil
+}
+
+// SetSubscribeOptions changes the options Subscribe uses from now on.
+func (s *SocketClientImpl) SetSubscribeOptions(opts SubscribeOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribeOptions = opts.withDefaults()
+}
+
+// DroppedEvents counts the events discarded by subscriptions that overflowed.
+func (s *SocketClientImpl) DroppedEvents() uint64 {
+	return s.droppedEvents.Load()
+}
+
+func (o SubscribeOptions) withDefaults() SubscribeOptions {
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultSubscribeOptions.BufferSize
+	}
+
+	if o.Overflow == "" {
+		o.Overflow = DefaultSubscribeOptions.Overflow
+	}
+
+	return o
+}
+
+// disconnected closes every open subscription so consumers observe the
+// connection loss instead of blocking on a channel that will never deliver.
+func (s *SocketClientImpl) disconnected() {
+	s.mu.RLock()
+	subscribers := make([]*subscription, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	s.mu.RUnlock()
+
+	for _, sub := range subscribers {
+		sub.close()
+	}
+}
@@ -0,0 +1,156 @@
+package freeswitch
+
+import (
+	"github.com/percipia/eslgo"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sequencedEvent(seq int) *Event {
+	return NewEvent(nil, &eslgo.Event{Headers: textproto.MIMEHeader{
+		"Event-Name":     {"DTMF"},
+		"Event-Sequence": {strconv.Itoa(seq)},
+	}})
+}
+
+// queuedSubscription is a subscription whose dispatcher is not started yet,
+// so the queue can be inspected.
+func queuedSubscription(size int, policy OverflowPolicy, dropped *atomic.Uint64) *subscription {
+	sub := &subscription{
+		size:    size,
+		events:  make(chan *Event),
+		done:    make(chan struct{}),
+		policy:  policy,
+		dropped: dropped,
+	}
+	sub.cond = sync.NewCond(&sub.mu)
+
+	return sub
+}
+
+func queuedSequences(sub *subscription) []uint64 {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	seqs := make([]uint64, len(sub.queue))
+	for i, e := range sub.queue {
+		seqs[i] = eventSequence(e)
+	}
+
+	return seqs
+}
+
+func equalSequences(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSubscriptionQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		policy      OverflowPolicy
+		send        []int
+		want        []uint64
+		wantDropped uint64
+	}{
+		{name: "restores order", size: 4, policy: OverflowBlock, send: []int{3, 1, 2}, want: []uint64{1, 2, 3}},
+		{name: "drop newest", size: 2, policy: OverflowDropNewest, send: []int{1, 2, 3}, want: []uint64{1, 2}, wantDropped: 1},
+		{name: "drop oldest", size: 2, policy: OverflowDropOldest, send: []int{1, 2, 3}, want: []uint64{2, 3}, wantDropped: 1},
+		{name: "drop oldest in order", size: 2, policy: OverflowDropOldest, send: []int{2, 1, 4, 3}, want: []uint64{3, 4}, wantDropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dropped atomic.Uint64
+			sub := queuedSubscription(tt.size, tt.policy, &dropped)
+
+			for _, seq := range tt.send {
+				sub.send(sequencedEvent(seq))
+			}
+
+			if got := queuedSequences(sub); !equalSequences(got, tt.want) {
+				t.Errorf("queue = %v, want %v", got, tt.want)
+			}
+			if got := dropped.Load(); got != tt.wantDropped {
+				t.Errorf("dropped = %v, want %v", got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestSubscriptionDispatch(t *testing.T) {
+	var dropped atomic.Uint64
+	sub := queuedSubscription(1, OverflowBlock, &dropped)
+
+	sub.send(sequencedEvent(1))
+
+	sent := make(chan struct{})
+	go func() {
+		sub.send(sequencedEvent(2))
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+		t.Fatal("send did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	go sub.dispatch()
+
+	for _, want := range []uint64{1, 2} {
+		select {
+		case e := <-sub.events:
+			if got := eventSequence(e); got != want {
+				t.Errorf("received event %v, want %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %v not delivered", want)
+		}
+	}
+
+	<-sent
+
+	sub.close()
+	select {
+	case _, ok := <-sub.events:
+		if ok {
+			t.Error("events still open after close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events not closed after close")
+	}
+}
+
+func TestSubscriptionCloseReleasesBlockedSend(t *testing.T) {
+	var dropped atomic.Uint64
+	sub := queuedSubscription(1, OverflowBlock, &dropped)
+	sub.send(sequencedEvent(1))
+
+	sent := make(chan struct{})
+	go func() {
+		sub.send(sequencedEvent(2))
+		close(sent)
+	}()
+
+	sub.close()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("blocked send not released by close")
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+type PrometheusReporter struct {
+	started   *prometheus.CounterVec
+	completed *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+}
+
+func NewPrometheusReporter(reg prometheus.Registerer) (*PrometheusReporter, error) {
+	r := &PrometheusReporter{
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "fsflow",
+			Name:      "activity_started_total",
+			Help:      "Number of activities started.",
+		}, []string{"activity"}),
+		completed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "fsflow",
+			Name:      "activity_completed_total",
+			Help:      "Number of activities completed, by outcome.",
+		}, []string{"activity", "success"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "fsflow",
+			Name:      "activity_duration_seconds",
+			Help:      "Activity execution time.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"activity"}),
+	}
+
+	for _, c := range []prometheus.Collector{r.started, r.completed, r.duration} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *PrometheusReporter) ActivityStarted(name string) {
+	r.started.WithLabelValues(name).Inc()
+}
+
+func (r *PrometheusReporter) ActivityCompleted(name string, duration time.Duration, success bool) {
+	r.completed.WithLabelValues(name, strconv.FormatBool(success)).Inc()
+	r.duration.WithLabelValues(name).Observe(duration.Seconds())
+}
+
+// RegisterDroppedEvents exposes how many events client's subscriptions had to
+// discard because their consumers fell behind.
+func RegisterDroppedEvents(reg prometheus.Registerer, client freeswitch.SocketClient) error {
+	return reg.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "fsflow",
+		Name:      "esl_dropped_events_total",
+		Help:      "Number of ESL events dropped by overflowing subscriptions.",
+	}, func() float64 {
+		return float64(client.DroppedEvents())
+	}))
+}
+
+// RegisterOriginatesInUse exposes how many originates are in flight against
+// the limit set with shared.SetOriginateLimiter.
+func RegisterOriginatesInUse(reg prometheus.Registerer) error {
+	return reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "fsflow",
+		Name:      "originates_in_use",
+		Help:      "Number of originates currently in flight.",
+	}, func() float64 {
+		return float64(shared.OriginateLimiter().InUse())
+	}))
+}
+
+var _ shared.MetricsReporter = (*PrometheusReporter)(nil)
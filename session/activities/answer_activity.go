@@ -0,0 +1,73 @@
+package activities
+
+import (
+	"context"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type AnswerActivityInput struct {
+	SessionId string `json:"sessionId"`
+}
+
+type AnswerActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const AnswerActivityName = "activities.AnswerActivity"
+
+func (c *AnswerActivity) Name() string {
+	return AnswerActivityName
+}
+
+func NewAnswerActivity(p freeswitch.SocketProvider) *AnswerActivity {
+	return &AnswerActivity{p: p}
+}
+
+func (c *AnswerActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := AnswerActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to AnswerActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to AnswerActivityInput"))
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_answer",
+			AppArgs: input.SessionId,
+		})
+
+		if err != nil {
+			logger.Error("Failed to answer session", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+
+		logger.Info("AnswerActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*AnswerActivity)(nil)
@@ -0,0 +1,80 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/freeswitch"
+	"sync"
+	"time"
+)
+
+// answerWatch records CHANNEL_ANSWER and CHANNEL_HANGUP per channel from the
+// moment it is started, so events arriving before the uuid is known count.
+type answerWatch struct {
+	mu      sync.Mutex
+	events  map[string]*freeswitch.Event
+	updated chan struct{}
+}
+
+func watchAnswers(ctx context.Context, client freeswitch.SocketClient) (*answerWatch, error) {
+	events, err := client.SubscribeWithOptions(ctx, []string{"CHANNEL_ANSWER", "CHANNEL_HANGUP"},
+		freeswitch.SubscribeOptions{Overflow: freeswitch.OverflowDropOldest})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &answerWatch{events: make(map[string]*freeswitch.Event), updated: make(chan struct{}, 1)}
+	go func() {
+		for e := range events {
+			w.mu.Lock()
+			uid := e.GetHeader("Unique-ID")
+			if prev, ok := w.events[uid]; !ok || prev.GetName() != "CHANNEL_HANGUP" {
+				w.events[uid] = e
+			}
+			w.mu.Unlock()
+
+			select {
+			case w.updated <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// confirm waits up to timeout for uid to answer. A hangup fails with its
+// cause, no event at all keeps the originate result.
+func (w *answerWatch) confirm(ctx context.Context, uid string, timeout time.Duration) (string, error) {
+	e, err := w.await(ctx, uid, timeout)
+	if err != nil || e == nil || e.GetName() == "CHANNEL_ANSWER" {
+		return "", err
+	}
+
+	cause := e.GetHeader("Hangup-Cause")
+	return cause, fmt.Errorf("channel %v hung up before answer: %v", uid, cause)
+}
+
+// await returns the answer or hangup event of uid, or nil after timeout.
+func (w *answerWatch) await(ctx context.Context, uid string, timeout time.Duration) (*freeswitch.Event, error) {
+	timer := clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		w.mu.Lock()
+		e := w.events[uid]
+		w.mu.Unlock()
+
+		if e != nil {
+			return e, nil
+		}
+
+		select {
+		case <-w.updated:
+		case <-timer.C():
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -6,14 +6,27 @@ import (
 	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/freeswitch"
 	"github.com/luongdev/fsflow/shared"
-	"go.uber.org/cadence/activity"
+	"github.com/luongdev/fsflow/tracing"
 	"go.uber.org/zap"
+	"strings"
+	"time"
 )
 
 type BridgeActivityInput struct {
 	Originator string `json:"originator"`
 	Originatee string `json:"originatee"`
 
+	VerifyChannel bool     `json:"verifyChannel"`
+	Exports       []string `json:"exports"`
+	ConfirmBridge bool     `json:"confirmBridge"`
+	// AwaitAnswer holds the bridge until the originatee answers, as bridging
+	// a leg still in early media leaves the audio one-way.
+	AwaitAnswer   bool          `json:"awaitAnswer"`
+	AnswerTimeout time.Duration `json:"answerTimeout"`
+	// AutoAnswerALeg answers the originator first when it is still ringing
+	// or in early media, which uuid_bridge cannot bridge.
+	AutoAnswerALeg bool `json:"autoAnswerALeg"`
+
 	shared.WorkflowInput
 }
 
@@ -33,12 +46,18 @@ func NewBridgeActivity(p freeswitch.SocketProvider) *BridgeActivity {
 
 func (c *BridgeActivity) Handler() shared.ActivityFunc {
 	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
-		logger := activity.GetLogger(ctx)
+		logger := shared.ActivityLogger(ctx, c.Name(), shared.SessionField(i.GetSessionId()))
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		report := shared.ReportActivity(c.Name())
+		defer func() { report(output.Success) }()
+
 		if err := i.Validate(); err != nil {
 			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
-			return output, err
+			return output, shared.NonRetryable(err)
 		}
 
 		client := c.p.GetClient(i.GetSessionId())
@@ -48,20 +67,82 @@ func (c *BridgeActivity) Handler() shared.ActivityFunc {
 
 		if !ok {
 			logger.Error("Failed to cast input to BridgeActivityInput")
-			return output, errors.NewWorkflowInputError("Cannot cast input to BridgeActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to BridgeActivityInput"))
 		}
 
-		res, err := client.Api(ctx, &freeswitch.Command{
-			AppName: "uuid_bridge",
-			AppArgs: fmt.Sprintf("%v %v", input.Originator, input.Originatee),
-		})
+		if input.VerifyChannel {
+			for _, uid := range []string{input.Originator, input.Originatee} {
+				exists, err := channelExists(ctx, client, uid)
+				if err != nil {
+					logger.Error("Failed to check channel", zap.Error(err))
+					return output, err
+				}
+
+				if !exists {
+					logger.Warn("Channel no longer exists", zap.String("uid", uid))
+					return output.WithMessage(channelGoneMessage), nil
+				}
+			}
+		}
+
+		for _, name := range input.Exports {
+			if !freeswitch.IsValidVarName(name) {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid export variable name '%v'", name)))
+			}
+		}
+
+		if len(input.Exports) > 0 {
+			if err := exportVariables(ctx, client, input.Originatee, input.Originator, input.Exports); err != nil {
+				logger.Error("Failed to export variables", zap.Strings("exports", input.Exports), zap.Error(err))
+				return output, err
+			}
+		}
+
+		if input.AutoAnswerALeg {
+			answered, err := channelAnswered(ctx, client, input.Originator)
+			if err != nil {
+				logger.Error("Failed to check originator answer state", zap.Error(err))
+				return output, err
+			}
+
+			if !answered {
+				cmd := &freeswitch.Command{AppName: "uuid_answer"}
+				if _, err := client.Api(ctx, cmd.WithArgs(input.Originator)); err != nil {
+					logger.Error("Failed to answer originator", zap.String("originator", input.Originator), zap.Error(err))
+					return output, err
+				}
+			}
+		}
+
+		if input.AwaitAnswer {
+			if err := awaitAnswer(ctx, client, input.Originatee, input.AnswerTimeout); err != nil {
+				logger.Error("Originatee did not answer", zap.String("originatee", input.Originatee), zap.Error(err))
+				return output.WithMessage(err.Error()), err
+			}
+		}
+
+		cmd := &freeswitch.Command{AppName: "uuid_bridge"}
+		res, err := client.Api(ctx, cmd.WithArgs(input.Originator, input.Originatee))
 
 		if err != nil {
 			return output, err
 		}
 
-		output.Success = true
-		output.Metadata[shared.FieldMessage] = res
+		if input.ConfirmBridge {
+			confirmed, err := confirmBridge(ctx, client, input.Originator, input.Originatee)
+			if err != nil {
+				logger.Error("Failed to confirm bridge", zap.Error(err))
+				return output, err
+			}
+
+			if !confirmed {
+				logger.Warn("Legs did not report a bridge", zap.Any("input", input))
+				return output.WithMessage(fmt.Sprintf("%v and %v were not bridged within %v",
+					input.Originator, input.Originatee, confirmBridgeTimeout)), nil
+			}
+		}
+
+		output.WithSuccess(true).WithMessage(res)
 
 		logger.Info("BridgeActivity completed", zap.Any("input", input))
 
@@ -69,4 +150,123 @@ func (c *BridgeActivity) Handler() shared.ActivityFunc {
 	}
 }
 
+const defaultAnswerTimeout = 30 * time.Second
+
+// awaitAnswer returns once uid has answered, checking answer_epoch after
+// subscribing so an answer in between is not missed.
+func awaitAnswer(ctx context.Context, client freeswitch.SocketClient, uid string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultAnswerTimeout
+	}
+
+	wCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	answers, err := watchAnswers(wCtx, client)
+	if err != nil {
+		return err
+	}
+
+	answered, err := channelAnswered(ctx, client, uid)
+	if err != nil || answered {
+		return err
+	}
+
+	e, err := answers.await(ctx, uid, timeout)
+	if err != nil {
+		return err
+	}
+
+	if e == nil {
+		return fmt.Errorf("%v did not answer within %v", uid, timeout)
+	}
+
+	if e.GetName() != "CHANNEL_ANSWER" {
+		return fmt.Errorf("%v hung up before answer: %v", uid, e.GetHeader("Hangup-Cause"))
+	}
+
+	return nil
+}
+
+const confirmBridgeTimeout = 3 * time.Second
+
+// confirmBridge waits for both legs to point at each other through
+// bridge_uuid, since uuid_bridge answers +OK before a failing leg drops.
+func confirmBridge(ctx context.Context, client freeswitch.SocketClient, a, b string) (bool, error) {
+	deadline := clock.Now().Add(confirmBridgeTimeout)
+	for {
+		confirmed := true
+		for _, leg := range [][2]string{{a, b}, {b, a}} {
+			res, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_getvar",
+				AppArgs: fmt.Sprintf("%v bridge_uuid", leg[0]),
+				NoCache: true,
+			})
+			if freeswitch.IsApiCause(err, freeswitch.CauseNoSuchChannel) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+
+			if res != leg[1] {
+				confirmed = false
+			}
+		}
+
+		if confirmed {
+			return true, nil
+		}
+
+		if clock.Now().After(deadline) {
+			return false, nil
+		}
+
+		t := clock.NewTimer(200 * time.Millisecond)
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return false, ctx.Err()
+		}
+	}
+}
+
+// exportVariables marks names for export on the a-leg and copies their current
+// values to the other leg, since uuid_bridge joins two existing channels and
+// never applies export_vars itself.
+func exportVariables(ctx context.Context, client freeswitch.SocketClient, from, to string, names []string) error {
+	_, err := client.Api(ctx, &freeswitch.Command{
+		AppName: "uuid_setvar",
+		AppArgs: fmt.Sprintf("%v export_vars %v", from, strings.Join(names, ",")),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		value, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_getvar",
+			AppArgs: fmt.Sprintf("%v %v", from, name),
+		})
+		if err != nil {
+			return err
+		}
+
+		if value == "" || value == undefinedVariable {
+			continue
+		}
+
+		_, err = client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_setvar",
+			AppArgs: fmt.Sprintf("%v %v %v", to, name, value),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var _ shared.FreeswitchActivity = (*BridgeActivity)(nil)
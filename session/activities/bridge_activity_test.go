@@ -0,0 +1,90 @@
+package activities
+
+import (
+	"context"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/shared/sharedtest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// bridgeVarClient answers uuid_getvar bridge_uuid, reporting the legs bridged
+// once polls exceeds after.
+type bridgeVarClient struct {
+	freeswitch.SocketClient
+	polls atomic.Int32
+	after int32
+}
+
+func (c *bridgeVarClient) Api(_ context.Context, cmd *freeswitch.Command) (string, error) {
+	if c.polls.Add(1) <= c.after {
+		return "", nil
+	}
+
+	if strings.HasPrefix(cmd.AppArgs, "a ") {
+		return "b", nil
+	}
+
+	return "a", nil
+}
+
+type confirmResult struct {
+	ok  bool
+	err error
+}
+
+// runConfirm runs confirmBridge against a mock clock, advancing it one poll
+// interval at a time, and reports the result with the time it took.
+func runConfirm(t *testing.T, client freeswitch.SocketClient) (confirmResult, time.Duration) {
+	t.Helper()
+
+	start := time.Unix(0, 0)
+	mock := sharedtest.NewClock(start)
+	defer func(c shared.Clock) { clock = c }(clock)
+	clock = mock
+
+	done := make(chan confirmResult, 1)
+	go func() {
+		ok, err := confirmBridge(context.Background(), client, "a", "b")
+		done <- confirmResult{ok: ok, err: err}
+	}()
+
+	for {
+		select {
+		case r := <-done:
+			return r, mock.Now().Sub(start)
+		default:
+		}
+
+		if mock.Waiters() > 0 {
+			mock.Advance(200 * time.Millisecond)
+			continue
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConfirmBridge(t *testing.T) {
+	r, took := runConfirm(t, &bridgeVarClient{after: 4})
+	if r.err != nil || !r.ok {
+		t.Fatalf("expected the bridge confirmed, got %v, %v", r.ok, r.err)
+	}
+
+	if took != 400*time.Millisecond {
+		t.Fatalf("expected the bridge confirmed on the third poll, took %v", took)
+	}
+}
+
+func TestConfirmBridgeTimeout(t *testing.T) {
+	r, took := runConfirm(t, &bridgeVarClient{after: 1 << 30})
+	if r.err != nil || r.ok {
+		t.Fatalf("expected the bridge unconfirmed, got %v, %v", r.ok, r.err)
+	}
+
+	if took <= confirmBridgeTimeout || took > confirmBridgeTimeout+200*time.Millisecond {
+		t.Fatalf("expected to give up right after %v, took %v", confirmBridgeTimeout, took)
+	}
+}
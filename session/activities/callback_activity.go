@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
 	"go.uber.org/cadence/activity"
 	"go.uber.org/zap"
 	"net/http"
@@ -66,9 +67,12 @@ func (c *CallbackActivity) Handler() shared.ActivityFunc {
 		logger := activity.GetLogger(ctx)
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
 		if err := i.Validate(); err != nil {
 			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
-			return output, err
+			return output, shared.NonRetryable(err)
 		}
 
 		input := CallbackActivityInput{}
@@ -0,0 +1,130 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+type CollectDigitsActivityInput struct {
+	SessionId     string        `json:"sessionId"`
+	Min           int           `json:"min"`
+	Max           int           `json:"max"`
+	Tries         int           `json:"tries"`
+	Timeout       time.Duration `json:"timeout"`
+	TerminatorKey string        `json:"terminatorKey"`
+	PromptFile    string        `json:"promptFile"`
+	InvalidFile   string        `json:"invalidFile"`
+	VariableName  string        `json:"variableName"`
+	Regex         string        `json:"regex"`
+}
+
+type CollectDigitsActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const CollectDigitsActivityName = "activities.CollectDigitsActivity"
+
+func (c *CollectDigitsActivity) Name() string {
+	return CollectDigitsActivityName
+}
+
+func NewCollectDigitsActivity(p freeswitch.SocketProvider) *CollectDigitsActivity {
+	return &CollectDigitsActivity{p: p}
+}
+
+func (c *CollectDigitsActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := CollectDigitsActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to CollectDigitsActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to CollectDigitsActivityInput"))
+		}
+
+		if input.PromptFile == "" {
+			return output, shared.NonRetryable(errors.RequireField("promptFile"))
+		}
+
+		if input.Min <= 0 {
+			input.Min = 1
+		}
+
+		if input.Max < input.Min {
+			input.Max = input.Min
+		}
+
+		if input.Tries <= 0 {
+			input.Tries = 1
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 5 * time.Second
+		}
+
+		if input.TerminatorKey == "" {
+			input.TerminatorKey = "#"
+		}
+
+		if input.InvalidFile == "" {
+			input.InvalidFile = "silence_stream://250"
+		}
+
+		if input.VariableName == "" {
+			input.VariableName = "collected_digits"
+		}
+
+		if input.Regex == "" {
+			input.Regex = "\\d+"
+		}
+
+		cmd := &freeswitch.Command{Uid: input.SessionId, AppName: "play_and_get_digits"}
+		cmd.WithArgs(strconv.Itoa(input.Min), strconv.Itoa(input.Max), strconv.Itoa(input.Tries),
+			strconv.FormatInt(input.Timeout.Milliseconds(), 10), input.TerminatorKey, input.PromptFile,
+			input.InvalidFile, input.VariableName, input.Regex)
+
+		res, e, err := executeAndWait(ctx, client, cmd)
+		if err != nil {
+			logger.Error("Failed to execute play_and_get_digits", zap.Error(err))
+			return output, err
+		}
+
+		output.Metadata[shared.FieldMessage] = res
+
+		digits := e.GetHeader(fmt.Sprintf("variable_%v", input.VariableName))
+		if digits == "" {
+			logger.Warn("No valid digits collected", zap.Any("input", input))
+			return output, nil
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldDigits] = digits
+
+		logger.Info("CollectDigitsActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*CollectDigitsActivity)(nil)
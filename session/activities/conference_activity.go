@@ -0,0 +1,159 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type ConferenceActivityInput struct {
+	SessionId      string   `json:"sessionId"`
+	ConferenceName string   `json:"conferenceName"`
+	Flags          []string `json:"flags"`
+	Profile        string   `json:"profile"`
+}
+
+var conferenceFlags = map[string]bool{
+	"mute":      true,
+	"deaf":      true,
+	"moderator": true,
+	"mintwo":    true,
+	"endconf":   true,
+	"nomoh":     true,
+	"ghost":     true,
+	"join-only": true,
+	"vmute":     true,
+}
+
+type ConferenceActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const ConferenceActivityName = "activities.ConferenceActivity"
+
+func (c *ConferenceActivity) Name() string {
+	return ConferenceActivityName
+}
+
+func NewConferenceActivity(p freeswitch.SocketProvider) *ConferenceActivity {
+	return &ConferenceActivity{p: p}
+}
+
+func (c *ConferenceActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := ConferenceActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to ConferenceActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to ConferenceActivityInput"))
+		}
+
+		if input.ConferenceName == "" {
+			return output, shared.NonRetryable(errors.RequireField("conferenceName"))
+		}
+
+		for _, f := range input.Flags {
+			if !conferenceFlags[f] {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported conference flag '%v'", f)))
+			}
+		}
+
+		if input.Profile == "" {
+			input.Profile = "default"
+		}
+
+		args := fmt.Sprintf("%v@%v", input.ConferenceName, input.Profile)
+		if len(input.Flags) > 0 {
+			args = fmt.Sprintf("%v+flags{%v}", args, strings.Join(input.Flags, "|"))
+		}
+
+		res, err := client.Execute(ctx, &freeswitch.Command{
+			Uid:     input.SessionId,
+			AppName: "conference",
+			AppArgs: args,
+		})
+
+		if err != nil {
+			logger.Error("Failed to join conference", zap.Error(err))
+			return output, err
+		}
+
+		memberId, err := c.memberId(ctx, client, input.SessionId)
+		if err != nil {
+			logger.Error("Failed to resolve conference member id", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+		output.Metadata[shared.FieldMemberId] = memberId
+
+		logger.Info("ConferenceActivity completed", zap.Any("input", input), zap.String("memberId", memberId))
+
+		return output, nil
+	}
+}
+
+const (
+	memberIdPollInterval = 200 * time.Millisecond
+	memberIdPollAttempts = 25
+	memberIdPollTimeout  = 10 * time.Second
+)
+
+// memberId polls for the member id conference sets on the channel once it has
+// joined, giving up after memberIdPollAttempts reads or memberIdPollTimeout.
+func (c *ConferenceActivity) memberId(ctx context.Context, client freeswitch.SocketClient, sessionId string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, memberIdPollTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(memberIdPollInterval)
+	defer ticker.Stop()
+
+	for attempt := 1; ; attempt++ {
+		res, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_getvar",
+			AppArgs: fmt.Sprintf("%v conference_member_id", sessionId),
+			NoCache: true,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if res != "" && res != undefinedVariable {
+			return res, nil
+		}
+
+		if attempt >= memberIdPollAttempts {
+			return "", fmt.Errorf("conference member id of %v not set after %v attempts", sessionId, attempt)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+var _ shared.FreeswitchActivity = (*ConferenceActivity)(nil)
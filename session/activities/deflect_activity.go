@@ -0,0 +1,111 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"regexp"
+	"strconv"
+)
+
+type DeflectActivityInput struct {
+	SessionId   string `json:"sessionId"`
+	Destination string `json:"destination"`
+}
+
+type DeflectActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const DeflectActivityName = "activities.DeflectActivity"
+
+var (
+	deflectURI    = regexp.MustCompile(`^(sips?:[^\s@]+@[^\s@]+|sips?:[^\s@]+|tel:\+?[0-9][0-9\-.()]*)$`)
+	deflectStatus = regexp.MustCompile(`\b([1-6][0-9]{2})\b`)
+)
+
+func (c *DeflectActivity) Name() string {
+	return DeflectActivityName
+}
+
+func NewDeflectActivity(p freeswitch.SocketProvider) *DeflectActivity {
+	return &DeflectActivity{p: p}
+}
+
+func (c *DeflectActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := DeflectActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to DeflectActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to DeflectActivityInput"))
+		}
+
+		if input.SessionId == "" {
+			return output, shared.NonRetryable(errors.RequireField("sessionId"))
+		}
+
+		if input.Destination == "" {
+			return output, shared.NonRetryable(errors.RequireField("destination"))
+		}
+
+		if !deflectURI.MatchString(input.Destination) {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("destination '%v' is not a sip or tel uri", input.Destination)))
+		}
+
+		cmd := &freeswitch.Command{AppName: "uuid_deflect"}
+		res, err := client.Api(ctx, cmd.WithArgs(input.SessionId, input.Destination))
+
+		status := referStatus(res)
+		if status > 0 {
+			output.Metadata[shared.FieldSipStatus] = status
+		}
+
+		if err != nil {
+			logger.Error("Failed to deflect call", zap.String("response", res), zap.Error(err))
+			if _, ok := err.(*freeswitch.ApiError); ok {
+				return output.WithMessage(res), nil
+			}
+			return output, err
+		}
+
+		output.WithSuccess(status < 300).WithMessage(res)
+
+		logger.Info("DeflectActivity completed", zap.Any("input", input), zap.Int("status", status))
+
+		return output, nil
+	}
+}
+
+// referStatus picks the SIP status the carrier answered the REFER with out of
+// the api reply, 0 when there is none.
+func referStatus(res string) int {
+	m := deflectStatus.FindStringSubmatch(res)
+	if m == nil {
+		return 0
+	}
+
+	status, _ := strconv.Atoi(m[1])
+	return status
+}
+
+var _ shared.FreeswitchActivity = (*DeflectActivity)(nil)
@@ -0,0 +1,98 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type DialplanExecuteActivityInput struct {
+	SessionId string `json:"sessionId"`
+	App       string `json:"app"`
+	Args      string `json:"args"`
+	Async     bool   `json:"async"`
+}
+
+// DialplanExecuteActivity runs an arbitrary dialplan application on a live
+// channel. It is a power-user escape hatch: nothing about App or Args is
+// checked beyond escaping, so prefer a dedicated activity where one exists.
+// Async broadcasts the application and returns at once, otherwise the activity
+// waits for the application to complete.
+type DialplanExecuteActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const DialplanExecuteActivityName = "activities.DialplanExecuteActivity"
+
+func (c *DialplanExecuteActivity) Name() string {
+	return DialplanExecuteActivityName
+}
+
+func NewDialplanExecuteActivity(p freeswitch.SocketProvider) *DialplanExecuteActivity {
+	return &DialplanExecuteActivity{p: p}
+}
+
+func (c *DialplanExecuteActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := DialplanExecuteActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to DialplanExecuteActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to DialplanExecuteActivityInput"))
+		}
+
+		if input.App == "" {
+			return output, shared.NonRetryable(errors.RequireField("app"))
+		}
+
+		var res string
+		var err error
+		if input.Async {
+			app := input.App
+			if input.Args != "" {
+				app = fmt.Sprintf("%v::%v", input.App, input.Args)
+			}
+
+			cmd := &freeswitch.Command{AppName: "uuid_broadcast"}
+			res, err = client.Api(ctx, cmd.WithArgs(input.SessionId, app, "aleg"))
+		} else {
+			res, _, err = executeAndWait(ctx, client, &freeswitch.Command{
+				Uid:     input.SessionId,
+				AppName: input.App,
+				AppArgs: freeswitch.EscapeHeader(input.Args),
+			})
+		}
+
+		if err != nil {
+			logger.Error("Failed to execute dialplan application", zap.String("app", input.App), zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("DialplanExecuteActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*DialplanExecuteActivity)(nil)
@@ -0,0 +1,96 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// displaceFlags are the uuid_displace flags: m mixes the file into the call
+// audio instead of replacing it, r and w pick the read or write direction.
+const displaceFlags = "mrw"
+
+type DisplaceActivityInput struct {
+	SessionId string `json:"sessionId"`
+	File      string `json:"file"`
+	Flags     string `json:"flags"`
+	Stop      bool   `json:"stop"`
+}
+
+type DisplaceActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const DisplaceActivityName = "activities.DisplaceActivity"
+
+func (c *DisplaceActivity) Name() string {
+	return DisplaceActivityName
+}
+
+func NewDisplaceActivity(p freeswitch.SocketProvider) *DisplaceActivity {
+	return &DisplaceActivity{p: p}
+}
+
+func (c *DisplaceActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := DisplaceActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to DisplaceActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to DisplaceActivityInput"))
+		}
+
+		if input.File == "" {
+			return output, shared.NonRetryable(errors.RequireField("file"))
+		}
+
+		for _, f := range input.Flags {
+			if !strings.ContainsRune(displaceFlags, f) {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid displace flag '%c'", f)))
+			}
+		}
+
+		cmd := &freeswitch.Command{AppName: "uuid_displace"}
+		if input.Stop {
+			cmd.WithArgs(input.SessionId, "stop", input.File)
+		} else if input.Flags != "" {
+			cmd.WithArgs(input.SessionId, "start", input.File, "0", input.Flags)
+		} else {
+			cmd.WithArgs(input.SessionId, "start", input.File)
+		}
+
+		res, err := client.Api(ctx, cmd)
+		if err != nil {
+			logger.Error("Failed to execute uuid_displace", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("DisplaceActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*DisplaceActivity)(nil)
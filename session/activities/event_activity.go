@@ -6,6 +6,7 @@ import (
 	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/freeswitch"
 	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
 	"go.uber.org/cadence/activity"
 	"go.uber.org/zap"
 )
@@ -37,9 +38,12 @@ func (c *EventActivity) Handler() shared.ActivityFunc {
 		logger := activity.GetLogger(ctx)
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
 		if err := i.Validate(); err != nil {
 			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
-			return output, err
+			return output, shared.NonRetryable(err)
 		}
 
 		client := c.p.GetClient(i.GetSessionId())
@@ -49,7 +53,7 @@ func (c *EventActivity) Handler() shared.ActivityFunc {
 
 		if !ok {
 			logger.Error("Failed to cast input to EventActivityInput")
-			return output, errors.NewWorkflowInputError("Cannot cast input to EventActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to EventActivityInput"))
 		}
 
 		if input.EventName == "" {
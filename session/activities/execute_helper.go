@@ -0,0 +1,64 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+)
+
+// clock drives the deadlines helpers compute, swapped in tests.
+var clock = shared.SystemClock
+
+// executeAndWait runs a dialplan application on the channel and blocks until
+// FreeSWITCH reports its CHANNEL_EXECUTE_COMPLETE event, which carries the
+// channel variables the application set.
+func executeAndWait(ctx context.Context, client freeswitch.SocketClient, cmd *freeswitch.Command) (string, *freeswitch.Event, error) {
+	done := make(chan *freeswitch.Event, 1)
+	lid := client.EventListener(cmd.Uid, func(e *freeswitch.Event) {
+		if e.GetName() == "CHANNEL_EXECUTE_COMPLETE" && e.GetHeader("Application") == cmd.AppName {
+			select {
+			case done <- e:
+			default:
+			}
+		}
+	})
+	defer client.RemoveEventListener(cmd.Uid, lid)
+
+	res, err := client.Execute(ctx, cmd)
+	if err != nil {
+		return res, nil, err
+	}
+
+	hb := startHeartbeat(ctx, cmd.AppName)
+	defer hb.Stop()
+
+	select {
+	case e := <-done:
+		return res, e, nil
+	case <-ctx.Done():
+		return res, nil, ctx.Err()
+	}
+}
+
+const channelGoneMessage = "channel no longer exists"
+
+func channelExists(ctx context.Context, client freeswitch.SocketClient, uid string) (bool, error) {
+	res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_exists", AppArgs: uid})
+	if err != nil {
+		return false, err
+	}
+
+	return res == "true", nil
+}
+
+// channelAnswered reads answer_epoch, which stays 0 while a channel is ringing
+// or only in early media.
+func channelAnswered(ctx context.Context, client freeswitch.SocketClient, uid string) (bool, error) {
+	res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_getvar", AppArgs: fmt.Sprintf("%v answer_epoch", uid), NoCache: true})
+	if err != nil {
+		return false, err
+	}
+
+	return res != "" && res != "0" && res != undefinedVariable, nil
+}
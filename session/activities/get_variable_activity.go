@@ -0,0 +1,84 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type GetVariableActivityInput struct {
+	SessionId string `json:"sessionId"`
+	Name      string `json:"name"`
+}
+
+type GetVariableActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const GetVariableActivityName = "activities.GetVariableActivity"
+
+const undefinedVariable = "_undef_"
+
+func (c *GetVariableActivity) Name() string {
+	return GetVariableActivityName
+}
+
+func NewGetVariableActivity(p freeswitch.SocketProvider) *GetVariableActivity {
+	return &GetVariableActivity{p: p}
+}
+
+func (c *GetVariableActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := GetVariableActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to GetVariableActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to GetVariableActivityInput"))
+		}
+
+		if input.Name == "" {
+			return output, shared.NonRetryable(errors.RequireField("name"))
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_getvar",
+			AppArgs: fmt.Sprintf("%v %v", input.SessionId, freeswitch.EscapeArg(input.Name)),
+		})
+
+		if err != nil {
+			logger.Error("Failed to get variable", zap.Error(err))
+			return output, err
+		}
+
+		if res == undefinedVariable {
+			output.Metadata[shared.FieldMessage] = fmt.Sprintf("variable %v is not set", input.Name)
+			return output, nil
+		}
+
+		output.Success = true
+		output.Metadata[shared.Field(input.Name)] = res
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*GetVariableActivity)(nil)
@@ -3,9 +3,10 @@ package activities
 import (
 	"context"
 	"fmt"
+	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/freeswitch"
 	"github.com/luongdev/fsflow/shared"
-	"go.uber.org/cadence/activity"
+	"github.com/luongdev/fsflow/tracing"
 	"go.uber.org/zap"
 )
 
@@ -13,6 +14,10 @@ type HangupActivityInput struct {
 	SessionId    string `json:"sessionId"`
 	HangupCause  string `json:"hangupCause"`
 	HangupReason string `json:"hangupReason"`
+
+	VerifyChannel bool `json:"verifyChannel"`
+	// HangupBoth also hangs up the leg the session is bridged to.
+	HangupBoth bool `json:"hangupBoth"`
 }
 
 type HangupActivity struct {
@@ -31,12 +36,18 @@ func NewHangupActivity(p freeswitch.SocketProvider) *HangupActivity {
 
 func (c *HangupActivity) Handler() shared.ActivityFunc {
 	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
-		logger := activity.GetLogger(ctx)
+		logger := shared.ActivityLogger(ctx, c.Name(), shared.SessionField(i.GetSessionId()))
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		report := shared.ReportActivity(c.Name())
+		defer func() { report(output.Success) }()
+
 		if err := i.Validate(); err != nil {
 			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
-			return output, err
+			return output, shared.NonRetryable(err)
 		}
 
 		client := c.p.GetClient(i.GetSessionId())
@@ -49,6 +60,28 @@ func (c *HangupActivity) Handler() shared.ActivityFunc {
 			return output, fmt.Errorf("failed to cast input to HangupActivityInput")
 		}
 
+		if input.HangupCause == "" {
+			input.HangupCause = string(shared.HangupNormalClearing)
+		}
+
+		if _, ok := shared.ParseHangupCause(input.HangupCause); !ok {
+			logger.Error("Invalid hangup cause", zap.String("hangupCause", input.HangupCause))
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unknown hangup cause '%v'", input.HangupCause)))
+		}
+
+		if input.VerifyChannel {
+			exists, err := channelExists(ctx, client, input.SessionId)
+			if err != nil {
+				logger.Error("Failed to check channel", zap.Error(err))
+				return output, err
+			}
+
+			if !exists {
+				logger.Warn("Channel no longer exists", zap.String("sessionId", input.SessionId))
+				return output.WithMessage(channelGoneMessage), nil
+			}
+		}
+
 		if input.HangupReason != "" {
 			res, err := client.Execute(ctx, &freeswitch.Command{
 				Uid:     input.SessionId,
@@ -61,20 +94,55 @@ func (c *HangupActivity) Handler() shared.ActivityFunc {
 			}
 		}
 
+		partner := ""
+		if input.HangupBoth {
+			res, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_getvar",
+				AppArgs: fmt.Sprintf("%v bridge_uuid", input.SessionId),
+				NoCache: true,
+			})
+			if err == nil && res != undefinedVariable {
+				partner = res
+			}
+		}
+
 		_, err := client.Api(ctx, &freeswitch.Command{
 			AppName: "uuid_kill",
 			AppArgs: fmt.Sprintf("%v %v", input.SessionId, input.HangupCause),
 		})
 
+		if freeswitch.IsApiCause(err, freeswitch.CauseNoSuchChannel) {
+			logger.Warn("Session already gone", zap.String("sessionId", input.SessionId))
+			output.WithSuccess(true).
+				WithMetadata(shared.FieldSessionId, input.SessionId).
+				WithMessage(fmt.Sprintf("Session %v no longer exists", input.SessionId))
+
+			return output, nil
+		}
+
 		if err != nil {
 			logger.Error("Failed to execute command", zap.Error(err))
 			return output, err
 		}
 
-		output.Success = true
-		output.Metadata[shared.FieldSessionId] = input.SessionId
-		output.Metadata[shared.FieldMessage] =
-			fmt.Sprintf("Session %v has been hungup cause: %v", input.SessionId, input.HangupCause)
+		hungup := []string{input.SessionId}
+		if partner != "" {
+			// The partner usually drops with the bridge already.
+			_, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_kill",
+				AppArgs: fmt.Sprintf("%v %v", partner, input.HangupCause),
+			})
+			if err != nil && !freeswitch.IsApiCause(err, freeswitch.CauseNoSuchChannel) {
+				logger.Error("Failed to hangup bridged leg", zap.String("partner", partner), zap.Error(err))
+				return output, err
+			}
+			hungup = append(hungup, partner)
+		}
+
+		output.WithSuccess(true).
+			WithMetadata(shared.FieldSessionId, input.SessionId).
+			WithMetadata(shared.FieldHungupSessions, hungup).
+			WithMessage(fmt.Sprintf("Session %v has been hungup cause: %v", input.SessionId, input.HangupCause))
 
 		return output, nil
 	}
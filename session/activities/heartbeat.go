@@ -0,0 +1,53 @@
+package activities
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"sync/atomic"
+	"time"
+)
+
+const defaultHeartbeatInterval = 5 * time.Second
+
+type heartbeater struct {
+	details atomic.Value
+	stop    chan struct{}
+}
+
+// startHeartbeat records the latest details on a ticker until Stop is called,
+// so long-blocking activities are not lost silently when the worker restarts.
+func startHeartbeat(ctx context.Context, details interface{}) *heartbeater {
+	h := &heartbeater{stop: make(chan struct{})}
+	h.details.Store(details)
+
+	interval := defaultHeartbeatInterval
+	if timeout := activity.GetInfo(ctx).HeartbeatTimeout; timeout > 0 && timeout/2 < interval {
+		interval = timeout / 2
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx, h.details.Load())
+			case <-h.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return h
+}
+
+func (h *heartbeater) Update(details interface{}) {
+	h.details.Store(details)
+}
+
+func (h *heartbeater) Stop() {
+	close(h.stop)
+}
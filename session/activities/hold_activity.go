@@ -0,0 +1,140 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type HoldActivityInput struct {
+	SessionId string `json:"sessionId"`
+	Hold      bool   `json:"hold"`
+	MOHFile   string `json:"mohFile"`
+}
+
+type HoldActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const HoldActivityName = "activities.HoldActivity"
+
+// holdStateVariable tracks the hold state set by this activity, as uuid_hold
+// fails when the channel is already in the requested state.
+const holdStateVariable = "fsflow_on_hold"
+
+func (c *HoldActivity) Name() string {
+	return HoldActivityName
+}
+
+func NewHoldActivity(p freeswitch.SocketProvider) *HoldActivity {
+	return &HoldActivity{p: p}
+}
+
+func (c *HoldActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := HoldActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to HoldActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to HoldActivityInput"))
+		}
+
+		held, err := onHold(ctx, client, input.SessionId)
+		if err != nil {
+			logger.Error("Failed to get hold state", zap.Error(err))
+			return output, err
+		}
+
+		if held == input.Hold {
+			logger.Info("Session already in requested hold state", zap.Any("input", input))
+
+			output.Success = true
+			output.Metadata[shared.FieldOnHold] = held
+
+			return output, nil
+		}
+
+		res, err := setHold(ctx, logger, client, input.SessionId, input.Hold, input.MOHFile)
+		if err != nil {
+			logger.Error("Failed to change hold state", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+		output.Metadata[shared.FieldOnHold] = input.Hold
+
+		logger.Info("HoldActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+func onHold(ctx context.Context, client freeswitch.SocketClient, sessionId string) (bool, error) {
+	state, err := client.Api(ctx, &freeswitch.Command{
+		AppName: "uuid_getvar",
+		AppArgs: fmt.Sprintf("%v %v", sessionId, holdStateVariable),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	held, _ := strconv.ParseBool(state)
+	return held, nil
+}
+
+// setHold puts sessionId on hold with mohFile looping as its hold_music, or
+// takes it off hold, recording the state HoldActivity checks.
+func setHold(ctx context.Context, logger *zap.Logger, client freeswitch.SocketClient, sessionId string, hold bool, mohFile string) (string, error) {
+	if hold && mohFile != "" {
+		_, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_setvar",
+			AppArgs: fmt.Sprintf("%v hold_music %v", sessionId, freeswitch.EscapeArg(mohFile)),
+		})
+		if err != nil {
+			return "", fmt.Errorf("cannot set hold music: %w", err)
+		}
+	}
+
+	args := sessionId
+	if !hold {
+		args = fmt.Sprintf("off %v", sessionId)
+	}
+
+	res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_hold", AppArgs: args})
+	if err != nil {
+		return res, err
+	}
+
+	_, err = client.Api(ctx, &freeswitch.Command{
+		AppName: "uuid_setvar",
+		AppArgs: fmt.Sprintf("%v %v %v", sessionId, holdStateVariable, hold),
+	})
+	if err != nil {
+		logger.Warn("Failed to record hold state", zap.Error(err))
+	}
+
+	return res, nil
+}
+
+var _ shared.FreeswitchActivity = (*HoldActivity)(nil)
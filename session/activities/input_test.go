@@ -0,0 +1,61 @@
+package activities
+
+import (
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/shared/sharedtest"
+	"testing"
+	"time"
+)
+
+func TestOriginateActivityInputRoundTrip(t *testing.T) {
+	sharedtest.AssertRoundTrip(t, OriginateActivityInput{
+		WorkflowInput:    shared.WorkflowInput{shared.FieldSessionId: "caller"},
+		Timeout:          30 * time.Second,
+		DialTimeout:      20 * time.Second,
+		DialedNumber:     "1001",
+		Destination:      "1001",
+		ANI:              "0900",
+		DNIS:             "1001",
+		Gateway:          "gw1",
+		Gateways:         []string{"gw1", "gw2"},
+		Profile:          "external",
+		AutoAnswer:       true,
+		AllowReject:      true,
+		Direction:        freeswitch.Outbound,
+		Domain:           "example.com",
+		Variables:        map[string]string{"foo": "bar"},
+		Extension:        "park",
+		Background:       true,
+		Callback:         "http://localhost/callback",
+		UniqueId:         "uid",
+		EarlyMedia:       true,
+		Endpoint:         "user/1001@example.com",
+		AutoAnswerVendor: "yealink",
+		DialTemplate:     "sofia/gateway/{{.Gateway}}/{{.Destination}}",
+		Headers:          map[string]string{"X-Tenant": "t1"},
+		MediaEncryption:  MediaEncryptionRequired,
+		Destinations:     []string{"1001", "1002"},
+		Strategy:         OriginateSequential,
+		AwaitAnswer:      true,
+		GatewaySelection: shared.SelectWeighted,
+		GatewayWeights:   map[string]int{"gw1": 3, "gw2": 1},
+		ConfirmPrompt:    "press-1.wav",
+		ConfirmDigit:     "1",
+		MOHDuringDial:    "local_stream://moh",
+	})
+}
+
+func TestBridgeActivityInputRoundTrip(t *testing.T) {
+	sharedtest.AssertRoundTrip(t, BridgeActivityInput{
+		Originator:     "a",
+		Originatee:     "b",
+		VerifyChannel:  true,
+		Exports:        []string{"foo"},
+		ConfirmBridge:  true,
+		AwaitAnswer:    true,
+		AnswerTimeout:  10 * time.Second,
+		AutoAnswerALeg: true,
+		WorkflowInput:  shared.WorkflowInput{shared.FieldSessionId: "a"},
+	})
+}
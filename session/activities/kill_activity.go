@@ -0,0 +1,89 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type KillActivityInput struct {
+	SessionId string `json:"sessionId"`
+	Cause     string `json:"cause"`
+}
+
+type KillActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const KillActivityName = "activities.KillActivity"
+
+func (c *KillActivity) Name() string {
+	return KillActivityName
+}
+
+func NewKillActivity(p freeswitch.SocketProvider) *KillActivity {
+	return &KillActivity{p: p}
+}
+
+func (c *KillActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := KillActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to KillActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to KillActivityInput"))
+		}
+
+		if input.SessionId == "" {
+			return output, shared.NonRetryable(errors.RequireField("sessionId"))
+		}
+
+		args := input.SessionId
+		if input.Cause != "" {
+			if _, ok := shared.ParseHangupCause(input.Cause); !ok {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unknown hangup cause '%v'", input.Cause)))
+			}
+			args = fmt.Sprintf("%v %v", args, input.Cause)
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_kill", AppArgs: args})
+		if freeswitch.IsApiCause(err, freeswitch.CauseNoSuchChannel) {
+			logger.Warn("Channel already gone", zap.String("sessionId", input.SessionId))
+			output.WithSuccess(true).WithMessage(channelGoneMessage)
+
+			return output, nil
+		}
+
+		if err != nil {
+			logger.Error("Failed to kill channel", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("KillActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*KillActivity)(nil)
@@ -0,0 +1,101 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type MuteActivityInput struct {
+	SessionId      string `json:"sessionId"`
+	MemberId       string `json:"memberId"`
+	ConferenceName string `json:"conferenceName"`
+	Mute           bool   `json:"mute"`
+}
+
+// MuteActivity mutes a conference member when MemberId is given, otherwise
+// the audio read from the SessionId leg.
+type MuteActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const MuteActivityName = "activities.MuteActivity"
+
+func (c *MuteActivity) Name() string {
+	return MuteActivityName
+}
+
+func NewMuteActivity(p freeswitch.SocketProvider) *MuteActivity {
+	return &MuteActivity{p: p}
+}
+
+func (c *MuteActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := MuteActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to MuteActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to MuteActivityInput"))
+		}
+
+		var cmd *freeswitch.Command
+		switch {
+		case input.MemberId != "":
+			if input.ConferenceName == "" {
+				return output, shared.NonRetryable(errors.RequireField("conferenceName"))
+			}
+
+			action := "unmute"
+			if input.Mute {
+				action = "mute"
+			}
+
+			cmd = &freeswitch.Command{AppName: "conference"}
+			cmd.WithArgs(input.ConferenceName, action, input.MemberId)
+		case input.SessionId != "":
+			args := fmt.Sprintf("%v stop", input.SessionId)
+			if input.Mute {
+				args = fmt.Sprintf("%v start read mute 1", input.SessionId)
+			}
+
+			cmd = &freeswitch.Command{AppName: "uuid_audio", AppArgs: args}
+		default:
+			return output, shared.NonRetryable(errors.NewRequireError("memberId", "either memberId or sessionId is required"))
+		}
+
+		res, err := client.Api(ctx, cmd)
+		if err != nil {
+			logger.Error("Failed to change mute state", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).
+			WithMessage(res).
+			WithMetadata(shared.FieldMuted, input.Mute)
+
+		logger.Info("MuteActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*MuteActivity)(nil)
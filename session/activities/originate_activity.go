@@ -2,32 +2,111 @@ package activities
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/freeswitch"
 	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
 	"go.uber.org/cadence/activity"
 	"go.uber.org/zap"
+	"regexp"
+	"strings"
 	"time"
 )
 
+type OriginateStrategy string
+
+const (
+	OriginateSimultaneous OriginateStrategy = "simultaneous"
+	OriginateSequential   OriginateStrategy = "sequential"
+)
+
+type MediaEncryption string
+
+const (
+	MediaEncryptionNone     MediaEncryption = "none"
+	MediaEncryptionOptional MediaEncryption = "optional"
+	MediaEncryptionRequired MediaEncryption = "required"
+)
+
+// rtpSecureMedia maps MediaEncryption to rtp_secure_media values.
+var rtpSecureMedia = map[MediaEncryption]string{
+	MediaEncryptionNone:     "false",
+	MediaEncryptionOptional: "optional",
+	MediaEncryptionRequired: "mandatory",
+}
+
 type OriginateActivityInput struct {
 	shared.WorkflowInput
 
-	Timeout      time.Duration          `json:"timeout"`
-	DialedNumber string                 `json:"dialedNumber"`
-	Destination  string                 `json:"destination"`
-	ANI          string                 `json:"ani"`
-	DNIS         string                 `json:"dnis"`
-	Gateway      string                 `json:"gateway"`
-	Profile      string                 `json:"profile"`
-	AutoAnswer   bool                   `json:"autoAnswer"`
-	AllowReject  bool                   `json:"allowReject"`
-	Direction    freeswitch.Direction   `json:"direction"`
-	Variables    map[string]interface{} `json:"variables"`
-	Extension    string                 `json:"extension"`
-	Background   bool                   `json:"background"`
-	Callback     string                 `json:"callback"`
+	Timeout      time.Duration        `json:"timeout"`
+	DialTimeout  time.Duration        `json:"dialTimeout"`
+	DialedNumber string               `json:"dialedNumber"`
+	Destination  string               `json:"destination"`
+	ANI          string               `json:"ani"`
+	DNIS         string               `json:"dnis"`
+	Gateway      string               `json:"gateway"`
+	Gateways     []string             `json:"gateways"`
+	Profile      string               `json:"profile"`
+	AutoAnswer   bool                 `json:"autoAnswer"`
+	AllowReject  bool                 `json:"allowReject"`
+	Direction    freeswitch.Direction `json:"direction"`
+	Domain       string               `json:"domain"`
+	Variables    map[string]string    `json:"variables"`
+	Extension    string               `json:"extension"`
+	Background   bool                 `json:"background"`
+	Callback     string               `json:"callback"`
+	UniqueId     string               `json:"uniqueId"`
+	EarlyMedia   bool                 `json:"earlyMedia"`
+	// Endpoint dials a phone directly, e.g. user/1001@domain. AutoAnswer only
+	// makes the phone pick up on such dials, gateway dials keep just X-Answer.
+	Endpoint         string `json:"endpoint"`
+	AutoAnswerVendor string `json:"autoAnswerVendor"`
+	// DialTemplate is a text/template rendered with Destination, Gateway and
+	// Profile; templates registered per gateway apply when it is empty.
+	DialTemplate string `json:"dialTemplate"`
+	// Headers go out on the INVITE as X- headers, the prefix being added
+	// when missing since carriers only pass custom headers carrying it.
+	Headers map[string]string `json:"headers"`
+
+	MediaEncryption MediaEncryption `json:"mediaEncryption"`
+
+	Destinations []string          `json:"destinations"`
+	Strategy     OriginateStrategy `json:"strategy"`
+	// AwaitAnswer confirms the answer with the CHANNEL_ANSWER event, as some
+	// originate forms reply before the far end truly answers.
+	AwaitAnswer bool `json:"awaitAnswer"`
+	// GatewaySelection picks which of Gateways is dialed first when Gateway
+	// is empty, the others remaining the failover order.
+	GatewaySelection shared.GatewaySelection `json:"gatewaySelection"`
+	GatewayWeights   map[string]int          `json:"gatewayWeights"`
+	// ConfirmPrompt is played to the answered leg before it is bridged, the
+	// bridge only happening once ConfirmDigit, 1 by default, is pressed.
+	ConfirmPrompt string `json:"confirmPrompt"`
+	ConfirmDigit  string `json:"confirmDigit"`
+	// MOHDuringDial holds the session with this hold music while the call is
+	// dialed. An answered call with an Extension keeps the hold, for
+	// OriginateProcessor to release before bridging.
+	MOHDuringDial string `json:"mohDuringDial"`
+}
+
+type originateProgress struct {
+	State    string `json:"state"`
+	UniqueId string `json:"uniqueId"`
+	Gateway  string `json:"gateway"`
+	// LegIds are the uuids of the legs a forked originate dials, UniqueId
+	// only naming the originate itself then.
+	LegIds []string `json:"legIds"`
+}
+
+// legs are the channels the originate may have created.
+func (p originateProgress) legs() []string {
+	if len(p.LegIds) > 0 {
+		return p.LegIds
+	}
+
+	return []string{p.UniqueId}
 }
 
 type OriginateActivity struct {
@@ -36,6 +115,8 @@ type OriginateActivity struct {
 
 const OriginateActivityName = "activities.OriginateActivity"
 
+var sipHeaderName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9\-]*$`)
+
 func (o *OriginateActivity) Name() string {
 	return OriginateActivityName
 }
@@ -49,6 +130,12 @@ func (o *OriginateActivity) Handler() shared.ActivityFunc {
 		logger := activity.GetLogger(ctx)
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 
+		ctx, span := tracing.StartActivity(ctx, o.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		report := shared.ReportActivity(o.Name())
+		defer func() { report(output.Success) }()
+
 		client := o.p.GetClient(i.GetSessionId())
 
 		input := OriginateActivityInput{}
@@ -56,8 +143,14 @@ func (o *OriginateActivity) Handler() shared.ActivityFunc {
 
 		if !ok {
 			logger.Error("Failed to cast input to OriginateActivityInput")
-			return output, errors.NewWorkflowInputError("Cannot cast input to OriginateActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to OriginateActivityInput"))
+		}
+
+		direction, err := freeswitch.ParseDirection(string(input.Direction))
+		if err != nil {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(err.Error()))
 		}
+		input.Direction = direction
 
 		if input.GetSessionId() == "" {
 			s, err := uuid.NewRandom()
@@ -66,43 +159,353 @@ func (o *OriginateActivity) Handler() shared.ActivityFunc {
 			}
 		}
 
-		if input.Variables == nil {
-			input.Variables = make(map[string]interface{})
+		variables := make(map[string]interface{}, len(input.Variables))
+		for k, v := range input.Variables {
+			if !freeswitch.IsValidVarName(k) {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid channel variable name '%v'", k)))
+			}
+			variables[k] = v
+		}
+
+		for name, v := range input.Headers {
+			header := name
+			if strings.HasPrefix(strings.ToUpper(header), "X-") {
+				header = header[2:]
+			}
+			if !sipHeaderName.MatchString(header) {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid sip header name '%v'", name)))
+			}
+			variables["X-"+header] = freeswitch.EscapeHeader(v)
 		}
 
 		if input.ANI != "" {
-			input.Variables["X-ANI"] = input.ANI
+			variables["X-ANI"] = input.ANI
 		}
 
 		if input.DNIS != "" {
-			input.Variables["X-DNIS"] = input.DNIS
-		}
-
-		res, err := client.Originate(ctx, &freeswitch.Originator{
-			SessionId:   input.GetSessionId(),
-			Callback:    input.Callback,
-			Timeout:     input.Timeout,
-			ANI:         input.DialedNumber,
-			DNIS:        input.Destination,
-			Direction:   input.Direction,
-			Profile:     input.Profile,
-			Gateway:     input.Gateway,
-			AutoAnswer:  input.AutoAnswer,
-			AllowReject: input.AllowReject,
-			Variables:   input.Variables,
-			Extension:   input.Extension,
-			Background:  input.Background,
-		})
+			variables["X-DNIS"] = input.DNIS
+		}
+
+		if input.EarlyMedia {
+			variables["ignore_early_media"] = false
+		}
+
+		if input.MediaEncryption != "" {
+			secure, ok := rtpSecureMedia[input.MediaEncryption]
+			if !ok {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported media encryption '%v'", input.MediaEncryption)))
+			}
+			variables["rtp_secure_media"] = secure
+		}
+
+		gateways := input.Gateways
+		if input.Gateway != "" {
+			gateways = append([]string{input.Gateway}, gateways...)
+		} else if input.GatewaySelection != "" {
+			selector, ok := shared.NewGatewaySelector(input.GatewaySelection, input.GatewayWeights)
+			if !ok {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported gateway selection '%v'", input.GatewaySelection)))
+			}
+
+			if len(gateways) > 1 {
+				gateways = selectGateway(selector, gateways)
+			}
+		}
+
+		if input.Endpoint != "" {
+			if len(gateways) > 0 || len(input.Destinations) > 0 {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError("endpoint cannot be combined with gateways or destinations"))
+			}
+			gateways = []string{""}
+		}
+
+		if _, ok := freeswitch.AutoAnswerHeaders[input.AutoAnswerVendor]; input.AutoAnswerVendor != "" && !ok {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unknown auto answer vendor '%v'", input.AutoAnswerVendor)))
+		}
+
+		if len(gateways) == 0 {
+			return output, shared.NonRetryable(errors.RequireField("gateway"))
+		}
+
+		if input.DialTemplate != "" {
+			target := freeswitch.DialTarget{Destination: input.Destination, Gateway: gateways[0], Profile: input.Profile}
+			if _, err := freeswitch.DialString(input.DialTemplate, target); err != nil {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(err.Error()))
+			}
+		}
+
+		switch input.Strategy {
+		case "", OriginateSimultaneous, OriginateSequential:
+		default:
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported originate strategy '%v'", input.Strategy)))
+		}
+
+		if input.MOHDuringDial != "" && !input.Background {
+			if err := o.startMOH(ctx, client, input.GetSessionId(), input.MOHDuringDial); err != nil {
+				logger.Warn("Failed to start music on hold", zap.Error(err))
+			} else {
+				defer func() {
+					if !output.Success || input.Extension == "" {
+						o.stopMOH(ctx, client, input.GetSessionId())
+					}
+				}()
+			}
+		}
+
+		progress := originateProgress{}
+		hb := startHeartbeat(ctx, progress)
+		defer hb.Stop()
+
+		if activity.HasHeartbeatDetails(ctx) {
+			if err := activity.GetHeartbeatDetails(ctx, &progress); err == nil && progress.UniqueId != "" {
+				hb.Update(progress)
+				uid, answered, err := o.resume(ctx, client, progress.legs())
+				if err == nil && answered {
+					logger.Info("Resumed in-progress originate", zap.Any("progress", progress))
+
+					output.Success = true
+					output.Metadata[shared.FieldUniqueId] = uid
+					output.Metadata[shared.FieldUsedGateway] = progress.Gateway
+
+					return output, nil
+				}
+			}
+		}
+
+		dialTimeout := input.DialTimeout
+		if dialTimeout == 0 {
+			dialTimeout = input.Timeout
+		}
+
+		limiter := shared.OriginateLimiter()
+		if err := limiter.Acquire(ctx); err != nil {
+			logger.Warn("No originate capacity left", zap.Int("inUse", limiter.InUse()), zap.Error(err))
+			return output, err
+		}
+		defer limiter.Release()
+
+		var answers *answerWatch
+		if input.AwaitAnswer && !input.Background {
+			wCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			w, err := watchAnswers(wCtx, client)
+			if err != nil {
+				logger.Warn("Failed to watch for answer, relying on originate result", zap.Error(err))
+			}
+			answers = w
+		}
+
+		breaker := shared.GatewayBreaker()
+
+		var res, cause, gateway string
+		for _, gateway = range gateways {
+			if gateway != "" && !breaker.Allow(gateway) {
+				logger.Warn("Gateway circuit open, skipping", zap.String("gateway", gateway))
+				cause = string(shared.HangupGatewayDown)
+				err = fmt.Errorf("gateway %v circuit open", gateway)
+				continue
+			}
+
+			uid := input.UniqueId
+			if uid == "" {
+				uid = uuid.New().String()
+			}
+
+			progress = originateProgress{State: "dialing", UniqueId: uid, Gateway: gateway}
+			if len(input.Destinations) > 0 {
+				progress.LegIds = freeswitch.ForkLegIds(uid, len(input.Destinations))
+			}
+			hb.Update(progress)
+
+			var reply string
+			res, cause = "", ""
+			reply, err = client.Originate(ctx, &freeswitch.Originator{
+				SessionId:   input.GetSessionId(),
+				UniqueId:    progress.UniqueId,
+				Callback:    input.Callback,
+				Timeout:     dialTimeout,
+				ANI:         input.DialedNumber,
+				DNIS:        input.Destination,
+				Direction:   input.Direction,
+				Domain:      input.Domain,
+				Profile:     input.Profile,
+				Gateway:     gateway,
+				AutoAnswer:  input.AutoAnswer,
+				AllowReject: input.AllowReject,
+				Variables:   variables,
+				Extension:   input.Extension,
+				Background:  input.Background,
+
+				Destinations: input.Destinations,
+				Sequential:   input.Strategy == OriginateSequential,
+
+				Endpoint:         input.Endpoint,
+				AutoAnswerVendor: input.AutoAnswerVendor,
+				DialTemplate:     input.DialTemplate,
+			})
+			if err == nil && input.Background {
+				res = reply
+			} else if err == nil {
+				res, cause, err = freeswitch.ParseOriginateResponse(reply)
+			}
+			if err == nil && answers != nil {
+				cause, err = answers.confirm(ctx, res, dialTimeout)
+			}
+			if gateway != "" {
+				_, lost := err.(*errors.ConnectionLostError)
+				hc, ok := shared.ParseHangupCause(cause)
+				if err == nil || ok && shared.IsTerminal(hc) {
+					breaker.Success(gateway)
+				} else if lost || ctx.Err() != nil {
+					breaker.Release(gateway)
+				} else {
+					breaker.Failure(gateway)
+				}
+			}
+
+			if err == nil {
+				break
+			}
+
+			logger.Warn("Failed to originate call via gateway", zap.String("gateway", gateway), zap.String("cause", cause), zap.Error(err))
+
+			if input.AllowReject && shared.IsTerminal(shared.HangupCause(cause)) {
+				break
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		if _, ok := err.(*errors.ConnectionLostError); ok {
+			logger.Error("Lost connection while originating call", zap.Error(err))
+			return output, err
+		}
+
 		if err != nil {
 			logger.Error("Failed to originate call", zap.Error(err))
+			output.Metadata[shared.FieldMessage] = cause
+			if hc, ok := shared.ParseHangupCause(cause); ok {
+				output.Metadata[shared.FieldHangupCause] = string(hc)
+
+				// A far end refusing SRTP answers 488, which maps to this cause.
+				if input.MediaEncryption == MediaEncryptionRequired && hc == shared.HangupIncompatibleDestination {
+					output.Metadata[shared.FieldMessage] = "far end does not support encrypted media"
+				}
+			}
 			return output, nil
 		}
 
+		hb.Update(originateProgress{State: "answered", UniqueId: res, Gateway: gateway})
+
 		output.Success = true
 		output.Metadata[shared.FieldUniqueId] = res
+		output.Metadata[shared.FieldUsedGateway] = gateway
+
+		if !input.Background {
+			if state, ok := o.mediaState(ctx, client, res); ok {
+				output.Metadata[shared.FieldMediaState] = string(state)
+			}
+		}
+
+		if len(input.Destinations) > 0 && !input.Background {
+			answered, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_getvar",
+				AppArgs: fmt.Sprintf("%v %v", res, freeswitch.DestinationVariable),
+			})
+			if err == nil && answered != undefinedVariable {
+				output.Metadata[shared.FieldAnsweredDestination] = answered
+			}
+		}
 
 		return output, nil
 	}
 }
 
+// selectGateway moves the gateway the selector picks to the front.
+func selectGateway(selector shared.GatewaySelector, gateways []string) []string {
+	pick := selector.Select(gateways)
+
+	ordered := append(make([]string, 0, len(gateways)), pick)
+	for _, g := range gateways {
+		if g != pick {
+			ordered = append(ordered, g)
+		}
+	}
+
+	return ordered
+}
+
+// startMOH holds the session with file as its hold music, which loops for as
+// long as the dial takes. A successful originate that goes on to a bridge
+// leaves the hold for the caller to release. A retried attempt finds the
+// session still held by the previous one.
+func (o *OriginateActivity) startMOH(ctx context.Context, client freeswitch.SocketClient, sessionId, file string) error {
+	if held, err := onHold(ctx, client, sessionId); err != nil || held {
+		return err
+	}
+
+	_, err := setHold(ctx, activity.GetLogger(ctx), client, sessionId, true, file)
+
+	return err
+}
+
+// stopMOH runs on a context of its own, the music must stop even when the
+// originate was cancelled.
+func (o *OriginateActivity) stopMOH(ctx context.Context, client freeswitch.SocketClient, sessionId string) {
+	_, _ = setHold(context.WithoutCancel(ctx), activity.GetLogger(ctx), client, sessionId, false, "")
+}
+
+// mediaState tells an answered leg from one that only established early media,
+// which is what originate returns on unless early media is ignored.
+func (o *OriginateActivity) mediaState(ctx context.Context, client freeswitch.SocketClient, uid string) (shared.MediaState, bool) {
+	res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_getvar", AppArgs: fmt.Sprintf("%v answer_epoch", uid)})
+	if err != nil {
+		return "", false
+	}
+
+	if res == "" || res == "0" || res == undefinedVariable {
+		return shared.MediaEarly, true
+	}
+
+	return shared.MediaAnswered, true
+}
+
+// resume waits for one of the legs dialed by a previous attempt of this
+// activity to answer, returning its uuid. It reports false once none of them
+// exists anymore and the call must be redialed.
+func (o *OriginateActivity) resume(ctx context.Context, client freeswitch.SocketClient, legs []string) (string, bool, error) {
+	for {
+		alive := false
+		for _, uid := range legs {
+			res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_exists", AppArgs: uid, NoCache: true})
+			if err != nil {
+				return "", false, err
+			}
+
+			if res != "true" {
+				continue
+			}
+			alive = true
+
+			res, err = client.Api(ctx, &freeswitch.Command{AppName: "uuid_getvar", AppArgs: fmt.Sprintf("%v answer_epoch", uid), NoCache: true})
+			if err == nil && res != "" && res != "0" && res != undefinedVariable {
+				return uid, true, nil
+			}
+		}
+
+		if !alive {
+			return "", false, nil
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		}
+	}
+}
+
 var _ shared.FreeswitchActivity = (*OriginateActivity)(nil)
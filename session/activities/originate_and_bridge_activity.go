@@ -0,0 +1,164 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"time"
+)
+
+type OriginateAndBridgeActivityInput struct {
+	SessionId   string        `json:"sessionId"`
+	Destination string        `json:"destination"`
+	Gateway     string        `json:"gateway"`
+	Profile     string        `json:"profile"`
+	UniqueId    string        `json:"uniqueId"`
+	Timeout     time.Duration `json:"timeout"`
+}
+
+// OriginateAndBridgeActivity has the caller's channel run the bridge
+// application itself, so the new leg is connected the moment it answers.
+type OriginateAndBridgeActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const OriginateAndBridgeActivityName = "activities.OriginateAndBridgeActivity"
+
+func (c *OriginateAndBridgeActivity) Name() string {
+	return OriginateAndBridgeActivityName
+}
+
+func NewOriginateAndBridgeActivity(p freeswitch.SocketProvider) *OriginateAndBridgeActivity {
+	return &OriginateAndBridgeActivity{p: p}
+}
+
+func (c *OriginateAndBridgeActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		report := shared.ReportActivity(c.Name())
+		defer func() { report(output.Success) }()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := OriginateAndBridgeActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to OriginateAndBridgeActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to OriginateAndBridgeActivityInput"))
+		}
+
+		if input.SessionId == "" {
+			return output, shared.NonRetryable(errors.RequireField("sessionId"))
+		}
+
+		if input.Destination == "" {
+			return output, shared.NonRetryable(errors.RequireField("destination"))
+		}
+
+		if input.Gateway == "" {
+			return output, shared.NonRetryable(errors.RequireField("gateway"))
+		}
+
+		if input.Profile == "" {
+			input.Profile = "external"
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 30 * time.Second
+		}
+
+		if input.UniqueId == "" {
+			input.UniqueId = uuid.New().String()
+		}
+
+		// Inline dialplans split on commas, so each variable block holds a
+		// single variable.
+		dial := fmt.Sprintf("{call_timeout=%v}[origination_uuid=%v]sofia/%v/%v@%v",
+			freeswitch.TimeoutSeconds(input.Timeout), input.UniqueId, input.Profile, input.Destination, input.Gateway)
+
+		result := make(chan *freeswitch.Event, 1)
+		lid := client.EventListener(input.SessionId, func(e *freeswitch.Event) {
+			switch e.GetName() {
+			case "CHANNEL_BRIDGE", "CHANNEL_HANGUP":
+			case "CHANNEL_EXECUTE_COMPLETE":
+				if e.GetHeader("Application") != "bridge" {
+					return
+				}
+			default:
+				return
+			}
+
+			select {
+			case result <- e:
+			default:
+			}
+		})
+		defer client.RemoveEventListener(input.SessionId, lid)
+
+		cmd := &freeswitch.Command{AppName: "uuid_transfer"}
+		res, err := client.Api(ctx, cmd.WithArgs(input.SessionId, fmt.Sprintf("bridge:%v", dial), "inline"))
+		if err != nil {
+			logger.Error("Failed to transfer to bridge", zap.Error(err))
+			return output, err
+		}
+
+		hb := startHeartbeat(ctx, input.UniqueId)
+		defer hb.Stop()
+
+		output.Metadata[shared.FieldSessionId] = input.SessionId
+		output.Metadata[shared.FieldUniqueId] = input.UniqueId
+
+		timer := time.NewTimer(input.Timeout + 5*time.Second)
+		defer timer.Stop()
+
+		select {
+		case e := <-result:
+			if e.GetName() == "CHANNEL_BRIDGE" {
+				output.WithSuccess(true).WithMessage(res)
+				logger.Info("OriginateAndBridgeActivity completed", zap.Any("input", input))
+
+				return output, nil
+			}
+
+			cause := e.GetHeader("variable_originate_disposition")
+			if cause == "" {
+				cause = e.GetHeader("Hangup-Cause")
+			}
+			logger.Warn("Bridge did not complete", zap.String("event", e.GetName()), zap.String("cause", cause))
+
+			output.WithMessage(cause)
+			if hc, ok := shared.ParseHangupCause(cause); ok {
+				output.Metadata[shared.FieldHangupCause] = string(hc)
+			}
+
+			return output, nil
+		case <-timer.C:
+			kill := &freeswitch.Command{AppName: "uuid_kill"}
+			_, _ = client.Api(ctx, kill.WithArgs(input.UniqueId, string(shared.HangupNoAnswer)))
+
+			output.Metadata[shared.FieldHangupCause] = string(shared.HangupNoAnswer)
+			return output.WithMessage(string(shared.HangupNoAnswer)), nil
+		case <-ctx.Done():
+			return output, ctx.Err()
+		}
+	}
+}
+
+var _ shared.FreeswitchActivity = (*OriginateAndBridgeActivity)(nil)
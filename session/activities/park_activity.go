@@ -0,0 +1,83 @@
+package activities
+
+import (
+	"context"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type ParkActivityInput struct {
+	SessionId string `json:"sessionId"`
+}
+
+// ParkActivity parks the session until it is moved elsewhere, e.g. by
+// TransferActivity once the workflow receives the signal it was waiting for.
+type ParkActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const ParkActivityName = "activities.ParkActivity"
+
+func (c *ParkActivity) Name() string {
+	return ParkActivityName
+}
+
+func NewParkActivity(p freeswitch.SocketProvider) *ParkActivity {
+	return &ParkActivity{p: p}
+}
+
+func (c *ParkActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := ParkActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to ParkActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to ParkActivityInput"))
+		}
+
+		exists, err := channelExists(ctx, client, input.SessionId)
+		if err != nil {
+			logger.Error("Failed to check session", zap.Error(err))
+			return output, err
+		}
+
+		if !exists {
+			logger.Warn("Session no longer exists", zap.String("sessionId", input.SessionId))
+			output.Metadata[shared.FieldMessage] = channelGoneMessage
+			return output, nil
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{AppName: "uuid_park", AppArgs: input.SessionId})
+		if err != nil {
+			logger.Error("Failed to park session", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+
+		logger.Info("ParkActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*ParkActivity)(nil)
@@ -0,0 +1,150 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"time"
+)
+
+type PlaybackActivityInput struct {
+	SessionId   string `json:"sessionId"`
+	File        string `json:"file"`
+	Loops       int    `json:"loops"`
+	Terminators string `json:"terminators"`
+}
+
+type PlaybackActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const PlaybackActivityName = "activities.PlaybackActivity"
+
+func (c *PlaybackActivity) Name() string {
+	return PlaybackActivityName
+}
+
+func NewPlaybackActivity(p freeswitch.SocketProvider) *PlaybackActivity {
+	return &PlaybackActivity{p: p}
+}
+
+func (c *PlaybackActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := PlaybackActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to PlaybackActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to PlaybackActivityInput"))
+		}
+
+		if input.File == "" {
+			return output, shared.NonRetryable(errors.RequireField("file"))
+		}
+
+		terminators := input.Terminators
+		if terminators == "" {
+			terminators = "none"
+		}
+
+		_, err := client.Execute(ctx, &freeswitch.Command{
+			Uid:     input.SessionId,
+			AppName: "set",
+			AppArgs: fmt.Sprintf("playback_terminators=%v", terminators),
+		})
+		if err != nil {
+			logger.Error("Failed to set playback terminators", zap.Error(err))
+			return output, err
+		}
+
+		stopped := make(chan string, 1)
+		hungup := make(chan struct{}, 1)
+		lid := client.EventListener(input.SessionId, func(e *freeswitch.Event) {
+			switch e.GetName() {
+			case "PLAYBACK_STOP":
+				select {
+				case stopped <- e.GetHeader("Playback-Status"):
+				default:
+				}
+			case "CHANNEL_HANGUP":
+				select {
+				case hungup <- struct{}{}:
+				default:
+				}
+			}
+		})
+		defer client.RemoveEventListener(input.SessionId, lid)
+
+		res, e, err := executeAndWait(ctx, client, &freeswitch.Command{
+			Uid:     input.SessionId,
+			AppName: "playback",
+			AppArgs: input.File,
+			Loops:   input.Loops,
+		})
+		if err != nil {
+			logger.Error("Failed to execute playback", zap.Error(err))
+			return output, err
+		}
+
+		if digit := e.GetHeader("variable_playback_terminator_used"); digit != "" {
+			output.Metadata[shared.FieldDigitPressed] = digit
+		}
+
+		output.Metadata[shared.FieldPlaybackResult] = string(playbackResult(e, stopped, hungup))
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+
+		logger.Info("PlaybackActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+// playbackResult reads how playback ended from PLAYBACK_STOP. Listeners run
+// concurrently, so the event may trail the execute completion a little.
+func playbackResult(e *freeswitch.Event, stopped <-chan string, hungup <-chan struct{}) shared.PlaybackResult {
+	status := ""
+	select {
+	case status = <-stopped:
+	case <-hungup:
+		return shared.PlaybackHangup
+	case <-time.After(250 * time.Millisecond):
+	}
+
+	select {
+	case <-hungup:
+		return shared.PlaybackHangup
+	default:
+	}
+
+	if e.GetHeader("Answer-State") == "hangup" {
+		return shared.PlaybackHangup
+	}
+
+	if status == "break" || status == "" && e.GetHeader("variable_playback_terminator_used") != "" {
+		return shared.PlaybackInterrupted
+	}
+
+	return shared.PlaybackCompleted
+}
+
+var _ shared.FreeswitchActivity = (*PlaybackActivity)(nil)
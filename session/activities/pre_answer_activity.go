@@ -0,0 +1,73 @@
+package activities
+
+import (
+	"context"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type PreAnswerActivityInput struct {
+	SessionId string `json:"sessionId"`
+}
+
+type PreAnswerActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const PreAnswerActivityName = "activities.PreAnswerActivity"
+
+func (c *PreAnswerActivity) Name() string {
+	return PreAnswerActivityName
+}
+
+func NewPreAnswerActivity(p freeswitch.SocketProvider) *PreAnswerActivity {
+	return &PreAnswerActivity{p: p}
+}
+
+func (c *PreAnswerActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := PreAnswerActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to PreAnswerActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to PreAnswerActivityInput"))
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_pre_answer",
+			AppArgs: input.SessionId,
+		})
+
+		if err != nil {
+			logger.Error("Failed to pre-answer session", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+
+		logger.Info("PreAnswerActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*PreAnswerActivity)(nil)
@@ -0,0 +1,90 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type RecordConferenceActivityInput struct {
+	SessionId      string `json:"sessionId"`
+	ConferenceName string `json:"conferenceName"`
+	Path           string `json:"path"`
+	Stop           bool   `json:"stop"`
+}
+
+type RecordConferenceActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const RecordConferenceActivityName = "activities.RecordConferenceActivity"
+
+func (c *RecordConferenceActivity) Name() string {
+	return RecordConferenceActivityName
+}
+
+func NewRecordConferenceActivity(p freeswitch.SocketProvider) *RecordConferenceActivity {
+	return &RecordConferenceActivity{p: p}
+}
+
+func (c *RecordConferenceActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := RecordConferenceActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to RecordConferenceActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to RecordConferenceActivityInput"))
+		}
+
+		if input.ConferenceName == "" {
+			return output, shared.NonRetryable(errors.RequireField("conferenceName"))
+		}
+
+		action, status, path := "start", "started", input.Path
+		if input.Stop {
+			action, status = "stop", "stopped"
+			if path == "" {
+				path = "all"
+			}
+		} else if path == "" {
+			return output, shared.NonRetryable(errors.RequireField("path"))
+		}
+
+		cmd := &freeswitch.Command{AppName: "conference"}
+		res, err := client.Api(ctx, cmd.WithArgs(input.ConferenceName, "recording", action, path))
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to %v conference recording", action), zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+		output.Metadata[shared.FieldRecordingPath] = input.Path
+		output.Metadata[shared.FieldRecordingStatus] = status
+
+		logger.Info("RecordConferenceActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*RecordConferenceActivity)(nil)
@@ -0,0 +1,151 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+type RecordSessionActivityInput struct {
+	SessionId          string `json:"sessionId"`
+	Path               string `json:"path"`
+	MaxDurationSeconds int    `json:"maxDurationSeconds"`
+	Stereo             bool   `json:"stereo"`
+	TerminatorKey      string `json:"terminatorKey"`
+	Stop               bool   `json:"stop"`
+}
+
+type RecordSessionActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const RecordSessionActivityName = "activities.RecordSessionActivity"
+
+const recordDigitRealm = "fsflow_record"
+
+func (c *RecordSessionActivity) Name() string {
+	return RecordSessionActivityName
+}
+
+func NewRecordSessionActivity(p freeswitch.SocketProvider) *RecordSessionActivity {
+	return &RecordSessionActivity{p: p}
+}
+
+func (c *RecordSessionActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := RecordSessionActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to RecordSessionActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to RecordSessionActivityInput"))
+		}
+
+		if input.Stop {
+			path := input.Path
+			if path == "" {
+				path = "all"
+			}
+
+			cmd := &freeswitch.Command{AppName: "uuid_record"}
+			res, err := client.Api(ctx, cmd.WithArgs(input.SessionId, "stop", path))
+			if err != nil {
+				logger.Error("Failed to stop recording", zap.Error(err))
+				return output, err
+			}
+
+			if input.TerminatorKey != "" {
+				_, _ = client.Execute(ctx, &freeswitch.Command{
+					Uid:     input.SessionId,
+					AppName: "clear_digit_action",
+					AppArgs: recordDigitRealm,
+				})
+			}
+
+			output.Success = true
+			output.Metadata[shared.FieldMessage] = res
+			output.Metadata[shared.FieldRecordingPath] = input.Path
+
+			return output, nil
+		}
+
+		if input.Path == "" {
+			return output, shared.NonRetryable(errors.RequireField("path"))
+		}
+
+		if input.Stereo {
+			_, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_setvar",
+				AppArgs: fmt.Sprintf("%v RECORD_STEREO true", input.SessionId),
+			})
+			if err != nil {
+				logger.Error("Failed to enable stereo recording", zap.Error(err))
+				return output, err
+			}
+		}
+
+		if input.TerminatorKey != "" {
+			_, err := client.Execute(ctx, &freeswitch.Command{
+				Uid:     input.SessionId,
+				AppName: "bind_digit_action",
+				AppArgs: freeswitch.EscapeHeader(fmt.Sprintf("%v,%v,api:uuid_record,%v stop %v",
+					recordDigitRealm, input.TerminatorKey, input.SessionId, freeswitch.EscapeArg(input.Path))),
+			})
+			if err != nil {
+				logger.Error("Failed to bind recording terminator", zap.Error(err))
+				return output, err
+			}
+
+			_, err = client.Execute(ctx, &freeswitch.Command{
+				Uid:     input.SessionId,
+				AppName: "digit_action_set_realm",
+				AppArgs: recordDigitRealm,
+			})
+			if err != nil {
+				logger.Error("Failed to set digit action realm", zap.Error(err))
+				return output, err
+			}
+		}
+
+		args := []string{input.SessionId, "start", input.Path}
+		if input.MaxDurationSeconds > 0 {
+			args = append(args, strconv.Itoa(input.MaxDurationSeconds))
+		}
+
+		cmd := &freeswitch.Command{AppName: "uuid_record"}
+		res, err := client.Api(ctx, cmd.WithArgs(args...))
+		if err != nil {
+			logger.Error("Failed to start recording", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+		output.Metadata[shared.FieldRecordingPath] = input.Path
+
+		logger.Info("RecordSessionActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*RecordSessionActivity)(nil)
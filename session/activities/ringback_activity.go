@@ -0,0 +1,93 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const toneStreamPrefix = "tone_stream://"
+
+type RingbackActivityInput struct {
+	SessionId    string `json:"sessionId"`
+	RingbackFile string `json:"ringbackFile"`
+	ToneStream   string `json:"toneStream"`
+}
+
+// RingbackActivity sets what the A-leg hears while the B-leg is dialed, so it
+// must run before the originate or transfer it applies to.
+type RingbackActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const RingbackActivityName = "activities.RingbackActivity"
+
+func (c *RingbackActivity) Name() string {
+	return RingbackActivityName
+}
+
+func NewRingbackActivity(p freeswitch.SocketProvider) *RingbackActivity {
+	return &RingbackActivity{p: p}
+}
+
+func (c *RingbackActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := RingbackActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to RingbackActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to RingbackActivityInput"))
+		}
+
+		if (input.RingbackFile == "") == (input.ToneStream == "") {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("exactly one of ringbackFile or toneStream is required"))
+		}
+
+		ringback := input.RingbackFile
+		if input.ToneStream != "" {
+			ringback = input.ToneStream
+			if !strings.HasPrefix(ringback, toneStreamPrefix) {
+				ringback = toneStreamPrefix + ringback
+			}
+		}
+
+		for _, name := range []string{"ringback", "transfer_ringback"} {
+			_, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_setvar",
+				AppArgs: fmt.Sprintf("%v %v %v", input.SessionId, name, freeswitch.EscapeHeader(ringback)),
+			})
+			if err != nil {
+				logger.Error("Failed to set ringback", zap.String("name", name), zap.Error(err))
+				return output, err
+			}
+		}
+
+		output.WithSuccess(true).WithMessage(ringback)
+
+		logger.Info("RingbackActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*RingbackActivity)(nil)
@@ -0,0 +1,100 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type ScheduleHangupActivityInput struct {
+	SessionId    string `json:"sessionId"`
+	AfterSeconds int    `json:"afterSeconds"`
+	Cause        string `json:"cause"`
+	Cancel       bool   `json:"cancel"`
+}
+
+// ScheduleHangupActivity lets FreeSWITCH itself tear the leg down after the
+// given delay, so the limit holds even when no worker is around to enforce it.
+type ScheduleHangupActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const ScheduleHangupActivityName = "activities.ScheduleHangupActivity"
+
+func (c *ScheduleHangupActivity) Name() string {
+	return ScheduleHangupActivityName
+}
+
+func NewScheduleHangupActivity(p freeswitch.SocketProvider) *ScheduleHangupActivity {
+	return &ScheduleHangupActivity{p: p}
+}
+
+func (c *ScheduleHangupActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := ScheduleHangupActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to ScheduleHangupActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to ScheduleHangupActivityInput"))
+		}
+
+		if input.SessionId == "" {
+			return output, shared.NonRetryable(errors.RequireField("sessionId"))
+		}
+
+		// sched_hangup schedules its task in a group named after the session,
+		// which is what sched_del removes.
+		cmd := &freeswitch.Command{AppName: "sched_del", AppArgs: input.SessionId}
+		if !input.Cancel {
+			if input.AfterSeconds <= 0 {
+				return output, shared.NonRetryable(errors.RequireField("afterSeconds"))
+			}
+
+			if input.Cause == "" {
+				input.Cause = string(shared.HangupAllottedTimeout)
+			}
+
+			if _, ok := shared.ParseHangupCause(input.Cause); !ok {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unknown hangup cause '%v'", input.Cause)))
+			}
+
+			cmd = &freeswitch.Command{
+				AppName: "sched_hangup",
+				AppArgs: fmt.Sprintf("+%v %v %v", input.AfterSeconds, input.SessionId, input.Cause),
+			}
+		}
+
+		res, err := client.Api(ctx, cmd)
+		if err != nil {
+			logger.Error("Failed to schedule hangup", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("ScheduleHangupActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*ScheduleHangupActivity)(nil)
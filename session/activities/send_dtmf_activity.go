@@ -0,0 +1,86 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"regexp"
+	"time"
+)
+
+type SendDTMFActivityInput struct {
+	SessionId    string        `json:"sessionId"`
+	Digits       string        `json:"digits"`
+	ToneDuration time.Duration `json:"toneDuration"`
+}
+
+var dtmfDigits = regexp.MustCompile(`^[0-9*#A-DwW]+$`)
+
+type SendDTMFActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const SendDTMFActivityName = "activities.SendDTMFActivity"
+
+func (c *SendDTMFActivity) Name() string {
+	return SendDTMFActivityName
+}
+
+func NewSendDTMFActivity(p freeswitch.SocketProvider) *SendDTMFActivity {
+	return &SendDTMFActivity{p: p}
+}
+
+func (c *SendDTMFActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := SendDTMFActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to SendDTMFActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SendDTMFActivityInput"))
+		}
+
+		if !dtmfDigits.MatchString(input.Digits) {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid dtmf digits '%v'", input.Digits)))
+		}
+
+		digits := input.Digits
+		if input.ToneDuration > 0 {
+			digits = fmt.Sprintf("%v@%v", digits, input.ToneDuration.Milliseconds())
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_send_dtmf",
+			AppArgs: fmt.Sprintf("%v %v", input.SessionId, digits),
+		})
+
+		if err != nil {
+			logger.Error("Failed to send dtmf", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*SendDTMFActivity)(nil)
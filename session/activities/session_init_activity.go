@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
 	"go.uber.org/cadence/activity"
 	"go.uber.org/zap"
 	"net/http"
@@ -21,6 +22,11 @@ type SessionInitActivityInput struct {
 	SessionId   string        `json:"sessionId"`
 }
 
+const (
+	defaultSessionInitTimeout = 10 * time.Second
+	sessionInitMargin         = 500 * time.Millisecond
+)
+
 type SessionInitActivity struct {
 }
 
@@ -37,9 +43,12 @@ func (s SessionInitActivity) Handler() shared.ActivityFunc {
 		logger := activity.GetLogger(ctx)
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 
+		ctx, span := tracing.StartActivity(ctx, s.Name(), i.GetSessionId())
+		defer span.Finish()
+
 		if err := i.Validate(); err != nil {
 			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
-			return output, err
+			return output, shared.NonRetryable(err)
 		}
 
 		input := SessionInitActivityInput{}
@@ -47,59 +56,126 @@ func (s SessionInitActivity) Handler() shared.ActivityFunc {
 
 		if !ok {
 			logger.Error("Failed to cast input to SessionInitActivityInput")
-			return output, errors.NewWorkflowInputError("Cannot cast input to SessionInitActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SessionInitActivityInput"))
 		}
 
-		bInput, err := json.Marshal(&input)
-		if err != nil {
-			logger.Error("Failed to marshal input", zap.Error(err))
-			return output, err
+		if input.Timeout <= 0 {
+			input.Timeout = defaultSessionInitTimeout
+		}
+
+		// Stop short of the activity deadline, which usually equals Timeout,
+		// so there is still time to report the hangup.
+		deadline := clock.Now().Add(input.Timeout)
+		if d, ok := ctx.Deadline(); ok && d.Add(-sessionInitMargin).Before(deadline) {
+			deadline = d.Add(-sessionInitMargin)
 		}
 
-		reqCtx, cancel := context.WithTimeout(ctx, input.Timeout)
+		reqCtx, cancel := context.WithDeadline(ctx, deadline)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, input.Initializer, bytes.NewBuffer(bInput))
-		if err != nil {
-			logger.Error("Failed to create request to init session", zap.Error(err))
-			return output, err
+		initializer, ok := shared.LookupSessionInitializer(input.Initializer)
+		if !ok {
+			initializer = httpInitializer{url: input.Initializer}
+		}
+
+		o, err := initializer.Init(reqCtx, i)
+		if err != nil && reqCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			logger.Warn("Initializer timed out", zap.Duration("timeout", input.Timeout))
+			return initTimeoutOutput(output, i.GetSessionId()), nil
 		}
-		req.Header.Set("Content-Type", "application/json")
-		res, err := http.DefaultClient.Do(req)
-
-		defer func(res *http.Response) {
-			if res != nil && res.Body != nil {
-				err := res.Body.Close()
-				if err != nil {
-					logger.Error("Failed to close response body", zap.Error(err))
-				}
-			}
-		}(res)
 
 		if err != nil {
-			logger.Error("Failed to send request to initializer", zap.Error(err))
+			logger.Error("Failed to init session", zap.String("initializer", input.Initializer), zap.Error(err))
 			return output, err
 		}
 
-		if res != nil && res.StatusCode != http.StatusOK {
-			logger.Error("Failed to init session", zap.Any("status", res.StatusCode))
-			return output, errors.NewWorkflowInputError("Failed to init session")
+		if o == nil {
+			return output, errors.NewWorkflowInputError("Initializer returned no output")
 		}
 
-		var o interface{}
-		err = json.NewDecoder(res.Body).Decode(&o)
-		if err != nil {
-			logger.Error("Failed to decode response body", zap.Error(err))
-			return output, err
+		if o.Metadata == nil {
+			o.Metadata = shared.Metadata{}
 		}
 
-		if ok := shared.Convert(o, &output); !ok {
-			logger.Error("Failed to cast response to WorkflowOutput")
-			return output, errors.NewWorkflowInputError("Cannot cast response to WorkflowOutput")
+		return o, nil
+	}
+}
+
+// httpInitializer posts the session to the initializer URL and decodes the
+// WorkflowOutput it answers with.
+type httpInitializer struct {
+	url string
+}
+
+func (h httpInitializer) Init(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+	logger := activity.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(i.GetSessionId())
+
+	input := SessionInitActivityInput{}
+	if ok := shared.ConvertInput(i, &input); !ok {
+		return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SessionInitActivityInput"))
+	}
+
+	bInput, err := json.Marshal(&input)
+	if err != nil {
+		logger.Error("Failed to marshal input", zap.Error(err))
+		return output, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewBuffer(bInput))
+	if err != nil {
+		logger.Error("Failed to create request to init session", zap.Error(err))
+		return output, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+
+	defer func(res *http.Response) {
+		if res != nil && res.Body != nil {
+			err := res.Body.Close()
+			if err != nil {
+				logger.Error("Failed to close response body", zap.Error(err))
+			}
 		}
+	}(res)
+
+	if err != nil {
+		return output, err
+	}
 
-		return output, nil
+	if res != nil && res.StatusCode != http.StatusOK {
+		logger.Error("Failed to init session", zap.Any("status", res.StatusCode))
+		return output, errors.NewWorkflowInputError("Failed to init session")
 	}
+
+	var o interface{}
+	err = json.NewDecoder(res.Body).Decode(&o)
+	if err != nil {
+		return output, err
+	}
+
+	if ok := shared.Convert(o, &output); !ok {
+		logger.Error("Failed to cast response to WorkflowOutput")
+		return output, errors.NewWorkflowInputError("Cannot cast response to WorkflowOutput")
+	}
+
+	return output, nil
+}
+
+// initTimeoutOutput hangs the session up when the initializer is too slow, so
+// the workflow carries on with a regular hangup instead of failing.
+func initTimeoutOutput(output *shared.WorkflowOutput, sessionId string) *shared.WorkflowOutput {
+	output.Success = true
+	output.Metadata[shared.FieldAction] = string(shared.ActionHangup)
+	output.Metadata[shared.FieldHangupCause] = string(shared.HangupAllottedTimeout)
+	output.Metadata[shared.FieldInput] = map[string]interface{}{
+		"sessionId":    sessionId,
+		"hangupCause":  string(shared.HangupAllottedTimeout),
+		"hangupReason": "InitTimeout",
+	}
+
+	return output
 }
 
 var _ shared.FreeswitchActivity = (*SessionInitActivity)(nil)
+var _ shared.SessionInitializer = httpInitializer{}
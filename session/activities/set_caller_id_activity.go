@@ -0,0 +1,99 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"regexp"
+)
+
+type SetCallerIdActivityInput struct {
+	SessionId string `json:"sessionId"`
+	Name      string `json:"name"`
+	Number    string `json:"number"`
+}
+
+type SetCallerIdActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const SetCallerIdActivityName = "activities.SetCallerIdActivity"
+
+var callerIdNumber = regexp.MustCompile(`^\+?[0-9]+$`)
+
+func (c *SetCallerIdActivity) Name() string {
+	return SetCallerIdActivityName
+}
+
+func NewSetCallerIdActivity(p freeswitch.SocketProvider) *SetCallerIdActivity {
+	return &SetCallerIdActivity{p: p}
+}
+
+func (c *SetCallerIdActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := SetCallerIdActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to SetCallerIdActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SetCallerIdActivityInput"))
+		}
+
+		if input.Number == "" {
+			return output, shared.NonRetryable(errors.RequireField("number"))
+		}
+
+		if !callerIdNumber.MatchString(input.Number) {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid caller id number '%v'", input.Number)))
+		}
+
+		name := input.Name
+		if name == "" {
+			name = input.Number
+		}
+
+		vars := [][2]string{
+			{"effective_caller_id_name", name},
+			{"effective_caller_id_number", input.Number},
+			{"outbound_caller_id_name", name},
+			{"outbound_caller_id_number", input.Number},
+		}
+
+		for _, v := range vars {
+			_, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_setvar",
+				AppArgs: fmt.Sprintf("%v %v %v", input.SessionId, v[0], freeswitch.EscapeArg(v[1])),
+			})
+			if err != nil {
+				logger.Error("Failed to set caller id", zap.String("variable", v[0]), zap.Error(err))
+				return output, err
+			}
+		}
+
+		output.WithSuccess(true).WithMessage(fmt.Sprintf("Caller id set to %v <%v>", name, input.Number))
+
+		logger.Info("SetCallerIdActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*SetCallerIdActivity)(nil)
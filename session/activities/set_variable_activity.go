@@ -0,0 +1,79 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type SetVariableActivityInput struct {
+	SessionId string `json:"sessionId"`
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+}
+
+type SetVariableActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const SetVariableActivityName = "activities.SetVariableActivity"
+
+func (c *SetVariableActivity) Name() string {
+	return SetVariableActivityName
+}
+
+func NewSetVariableActivity(p freeswitch.SocketProvider) *SetVariableActivity {
+	return &SetVariableActivity{p: p}
+}
+
+func (c *SetVariableActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := SetVariableActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to SetVariableActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SetVariableActivityInput"))
+		}
+
+		if input.Name == "" {
+			return output, shared.NonRetryable(errors.RequireField("name"))
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{
+			AppName: "uuid_setvar",
+			AppArgs: fmt.Sprintf("%v %v %v", input.SessionId, freeswitch.EscapeArg(input.Name), freeswitch.EscapeArg(input.Value)),
+		})
+
+		if err != nil {
+			logger.Error("Failed to set variable", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+		output.Metadata[shared.Field(input.Name)] = input.Value
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*SetVariableActivity)(nil)
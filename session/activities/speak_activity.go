@@ -0,0 +1,87 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type SpeakActivityInput struct {
+	SessionId string `json:"sessionId"`
+	Engine    string `json:"engine"`
+	Voice     string `json:"voice"`
+	Text      string `json:"text"`
+}
+
+type SpeakActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const SpeakActivityName = "activities.SpeakActivity"
+
+func (c *SpeakActivity) Name() string {
+	return SpeakActivityName
+}
+
+func NewSpeakActivity(p freeswitch.SocketProvider) *SpeakActivity {
+	return &SpeakActivity{p: p}
+}
+
+func (c *SpeakActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := SpeakActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to SpeakActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SpeakActivityInput"))
+		}
+
+		if input.Engine == "" {
+			return output, shared.NonRetryable(errors.RequireField("engine"))
+		}
+
+		if input.Voice == "" {
+			return output, shared.NonRetryable(errors.RequireField("voice"))
+		}
+
+		if input.Text == "" {
+			return output, shared.NonRetryable(errors.RequireField("text"))
+		}
+
+		cmd := &freeswitch.Command{AppName: "uuid_broadcast"}
+		cmd.WithArgs(input.SessionId, fmt.Sprintf("speak::%v|%v|%v", input.Engine, input.Voice, input.Text), "aleg")
+
+		res, err := client.Api(ctx, cmd)
+		if err != nil {
+			logger.Error("Failed to speak text", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("SpeakActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*SpeakActivity)(nil)
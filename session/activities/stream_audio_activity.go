@@ -0,0 +1,137 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+	"net/url"
+)
+
+type StreamDirection string
+
+const (
+	StreamDirectionRead  StreamDirection = "read"
+	StreamDirectionBoth  StreamDirection = "both"
+	StreamDirectionSplit StreamDirection = "split"
+)
+
+var streamMixTypes = map[StreamDirection]string{
+	StreamDirectionRead:  "mono",
+	StreamDirectionBoth:  "mixed",
+	StreamDirectionSplit: "stereo",
+}
+
+type StreamMode string
+
+const (
+	StreamModeFork   StreamMode = "fork"
+	StreamModeStream StreamMode = "stream"
+)
+
+var streamCommands = map[StreamMode]string{
+	StreamModeFork:   "uuid_audio_fork",
+	StreamModeStream: "uuid_audio_stream",
+}
+
+type StreamAudioActivityInput struct {
+	SessionId    string          `json:"sessionId"`
+	WSUrl        string          `json:"wsUrl"`
+	Direction    StreamDirection `json:"direction"`
+	Mode         StreamMode      `json:"mode"`
+	SamplingRate int             `json:"samplingRate"`
+	Stop         bool            `json:"stop"`
+}
+
+type StreamAudioActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const StreamAudioActivityName = "activities.StreamAudioActivity"
+
+func (c *StreamAudioActivity) Name() string {
+	return StreamAudioActivityName
+}
+
+func NewStreamAudioActivity(p freeswitch.SocketProvider) *StreamAudioActivity {
+	return &StreamAudioActivity{p: p}
+}
+
+func (c *StreamAudioActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := StreamAudioActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to StreamAudioActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to StreamAudioActivityInput"))
+		}
+
+		if input.Mode == "" {
+			input.Mode = StreamModeFork
+		}
+
+		appName, ok := streamCommands[input.Mode]
+		if !ok {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported stream mode '%v'", input.Mode)))
+		}
+
+		args := fmt.Sprintf("%v stop", input.SessionId)
+		if !input.Stop {
+			u, err := url.Parse(input.WSUrl)
+			if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("invalid websocket url '%v'", input.WSUrl)))
+			}
+
+			if input.Direction == "" {
+				input.Direction = StreamDirectionRead
+			}
+
+			mixType, ok := streamMixTypes[input.Direction]
+			if !ok {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported stream direction '%v'", input.Direction)))
+			}
+
+			if input.SamplingRate == 0 {
+				input.SamplingRate = 8000
+			}
+
+			if input.SamplingRate != 8000 && input.SamplingRate != 16000 {
+				return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported sampling rate %v", input.SamplingRate)))
+			}
+
+			args = fmt.Sprintf("%v start %v %v %vk", input.SessionId, u.String(), mixType, input.SamplingRate/1000)
+		}
+
+		res, err := client.Api(ctx, &freeswitch.Command{AppName: appName, AppArgs: args})
+		if err != nil {
+			logger.Error("Failed to change audio stream", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("StreamAudioActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*StreamAudioActivity)(nil)
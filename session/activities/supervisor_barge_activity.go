@@ -0,0 +1,120 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type BargeMode string
+
+const (
+	BargeModeListen  BargeMode = "listen"
+	BargeModeWhisper BargeMode = "whisper"
+	BargeModeBarge   BargeMode = "barge"
+)
+
+// bargeModes holds which side of the target call hears the supervisor:
+// the target leg itself and the leg it is bridged to.
+var bargeModes = map[BargeMode][2]bool{
+	BargeModeListen:  {false, false},
+	BargeModeWhisper: {true, false},
+	BargeModeBarge:   {true, true},
+}
+
+type SupervisorBargeActivityInput struct {
+	SupervisorSession string    `json:"supervisorSession"`
+	TargetSession     string    `json:"targetSession"`
+	Mode              BargeMode `json:"mode"`
+}
+
+type SupervisorBargeActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const SupervisorBargeActivityName = "activities.SupervisorBargeActivity"
+
+func (c *SupervisorBargeActivity) Name() string {
+	return SupervisorBargeActivityName
+}
+
+func NewSupervisorBargeActivity(p freeswitch.SocketProvider) *SupervisorBargeActivity {
+	return &SupervisorBargeActivity{p: p}
+}
+
+func (c *SupervisorBargeActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := SupervisorBargeActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to SupervisorBargeActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to SupervisorBargeActivityInput"))
+		}
+
+		if input.SupervisorSession == "" {
+			return output, shared.NonRetryable(errors.RequireField("supervisorSession"))
+		}
+
+		if input.TargetSession == "" {
+			return output, shared.NonRetryable(errors.RequireField("targetSession"))
+		}
+
+		if input.Mode == "" {
+			input.Mode = BargeModeListen
+		}
+
+		whisper, ok := bargeModes[input.Mode]
+		if !ok {
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported barge mode '%v'", input.Mode)))
+		}
+
+		vars := map[string]bool{"eavesdrop_whisper_aleg": whisper[0], "eavesdrop_whisper_bleg": whisper[1]}
+		for name, value := range vars {
+			_, err := client.Api(ctx, &freeswitch.Command{
+				AppName: "uuid_setvar",
+				AppArgs: fmt.Sprintf("%v %v %v", input.SupervisorSession, name, value),
+			})
+			if err != nil {
+				logger.Error("Failed to set eavesdrop variable", zap.String("name", name), zap.Error(err))
+				return output, err
+			}
+		}
+
+		res, err := client.Execute(ctx, &freeswitch.Command{
+			Uid:     input.SupervisorSession,
+			AppName: "eavesdrop",
+			AppArgs: input.TargetSession,
+		})
+		if err != nil {
+			logger.Error("Failed to start eavesdrop", zap.Error(err))
+			return output, err
+		}
+
+		output.WithSuccess(true).WithMessage(res)
+
+		logger.Info("SupervisorBargeActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*SupervisorBargeActivity)(nil)
@@ -0,0 +1,108 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+type TransferLeg string
+
+const (
+	TransferLegDefault TransferLeg = ""
+	TransferLegB       TransferLeg = "bleg"
+	TransferLegBoth    TransferLeg = "both"
+)
+
+type TransferActivityInput struct {
+	SessionId string      `json:"sessionId"`
+	Extension string      `json:"extension"`
+	Dialplan  string      `json:"dialplan"`
+	Context   string      `json:"context"`
+	Leg       TransferLeg `json:"leg"`
+}
+
+type TransferActivity struct {
+	p freeswitch.SocketProvider
+}
+
+const TransferActivityName = "activities.TransferActivity"
+
+func (c *TransferActivity) Name() string {
+	return TransferActivityName
+}
+
+func NewTransferActivity(p freeswitch.SocketProvider) *TransferActivity {
+	return &TransferActivity{p: p}
+}
+
+func (c *TransferActivity) Handler() shared.ActivityFunc {
+	return func(ctx context.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := activity.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		ctx, span := tracing.StartActivity(ctx, c.Name(), i.GetSessionId())
+		defer span.Finish()
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, shared.NonRetryable(err)
+		}
+
+		client := c.p.GetClient(i.GetSessionId())
+
+		input := TransferActivityInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to TransferActivityInput")
+			return output, shared.NonRetryable(errors.NewWorkflowInputError("Cannot cast input to TransferActivityInput"))
+		}
+
+		if input.Extension == "" {
+			return output, shared.NonRetryable(errors.RequireField("extension"))
+		}
+
+		args := []string{input.SessionId}
+		switch input.Leg {
+		case TransferLegDefault:
+		case TransferLegB, TransferLegBoth:
+			args = append(args, fmt.Sprintf("-%v", input.Leg))
+		default:
+			return output, shared.NonRetryable(errors.NewWorkflowInputError(fmt.Sprintf("unsupported transfer leg '%v'", input.Leg)))
+		}
+
+		args = append(args, input.Extension)
+		if input.Dialplan != "" || input.Context != "" {
+			dialplan := input.Dialplan
+			if dialplan == "" {
+				dialplan = "XML"
+			}
+			args = append(args, dialplan)
+		}
+		if input.Context != "" {
+			args = append(args, input.Context)
+		}
+
+		cmd := &freeswitch.Command{AppName: "uuid_transfer"}
+		res, err := client.Api(ctx, cmd.WithArgs(args...))
+		if err != nil {
+			logger.Error("Failed to transfer session", zap.Error(err))
+			return output, err
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldMessage] = res
+
+		logger.Info("TransferActivity completed", zap.Any("input", input))
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchActivity = (*TransferActivity)(nil)
@@ -56,9 +56,23 @@ func (p *FreeswitchActivityProcessorImpl) GetInput(metadata shared.Metadata, i i
 		return fmt.Errorf("cannot found action")
 	}
 
-	ok := shared.ConvertInput(metadata.GetInput(), &i)
-	if !ok {
-		return fmt.Errorf("cannot cast input for action: %v", metadata.GetAction())
+	// Keys other than action and input ride along in the embedded
+	// WorkflowInput, so activities can read data the initializer set.
+	in := metadata.GetInput()
+	carried := shared.Metadata{shared.FieldSessionId: in.GetSessionId()}
+	if wi, ok := in["WorkflowInput"].(map[string]interface{}); ok {
+		for k, v := range wi {
+			carried[shared.Field(k)] = v
+		}
+	}
+	metadata.CopyInto(carried)
+	delete(carried, shared.FieldAction)
+	delete(carried, shared.FieldInput)
+	in["WorkflowInput"] = carried
+
+	err := shared.ConvertInputE(in, &i)
+	if err != nil {
+		return fmt.Errorf("cannot cast input for action %v: %w", metadata.GetAction(), err)
 	}
 
 	return nil
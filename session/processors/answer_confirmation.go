@@ -0,0 +1,57 @@
+package processors
+
+import (
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	defaultConfirmDigit   = "1"
+	defaultConfirmTimeout = 5 * time.Second
+)
+
+// ConfirmAnswer plays prompt to the answered leg uid and reports whether
+// digit was pressed, telling a person from a machine or silence. Unconfirmed
+// legs are killed since nothing will be bridged to them.
+func ConfirmAnswer(ctx workflow.Context, aP session.ActivityProvider, uid, prompt, digit string) (bool, error) {
+	logger := workflow.GetLogger(ctx)
+
+	if digit == "" {
+		digit = defaultConfirmDigit
+	}
+
+	cA := aP.GetActivity(activities.CollectDigitsActivityName)
+	cOutput := shared.NewWorkflowOutput(uid)
+	err := workflow.ExecuteActivity(ctx, cA.Handler(), activities.CollectDigitsActivityInput{
+		SessionId:  uid,
+		Min:        1,
+		Max:        1,
+		Tries:      1,
+		Timeout:    defaultConfirmTimeout,
+		PromptFile: prompt,
+	}).Get(ctx, cOutput)
+	if err != nil {
+		return false, err
+	}
+
+	if pressed, _ := cOutput.Metadata.GetString(shared.FieldDigits); cOutput.Success && pressed == digit {
+		return true, nil
+	}
+
+	logger.Info("Answer not confirmed", zap.String("uniqueId", uid), zap.Any("output", cOutput))
+
+	kA := aP.GetActivity(activities.KillActivityName)
+	err = workflow.ExecuteActivity(ctx, kA.Handler(), activities.KillActivityInput{
+		SessionId: uid,
+		Cause:     string(shared.HangupNormalClearing),
+	}).Get(ctx, nil)
+	if err != nil {
+		logger.Error("Failed to kill unconfirmed leg", zap.String("uniqueId", uid), zap.Error(err))
+	}
+
+	return false, nil
+}
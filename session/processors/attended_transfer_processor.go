@@ -0,0 +1,303 @@
+package processors
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const AttendedTransferSignal = "attended_transfer"
+
+type AttendedTransferResult string
+
+const (
+	AttendedTransferComplete AttendedTransferResult = "complete"
+	AttendedTransferCancel   AttendedTransferResult = "cancel"
+)
+
+type AttendedTransferInput struct {
+	SessionId      string        `json:"sessionId"`
+	AgentId        string        `json:"agentId"`
+	Destination    string        `json:"destination"`
+	Gateway        string        `json:"gateway"`
+	Profile        string        `json:"profile"`
+	MOHFile        string        `json:"mohFile"`
+	Timeout        time.Duration `json:"timeout"`
+	ConsultTimeout time.Duration `json:"consultTimeout"`
+}
+
+// AttendedTransferProcessor parks the caller, dials a consult leg for the
+// agent and then, on an AttendedTransferSignal or after ConsultTimeout, either
+// hands the caller over to the consult leg or returns them to the agent.
+type AttendedTransferProcessor struct {
+	*FreeswitchActivityProcessorImpl
+}
+
+func NewAttendedTransferProcessor(w shared.FreeswitchWorkflow, aP session.ActivityProvider) *AttendedTransferProcessor {
+	return &AttendedTransferProcessor{FreeswitchActivityProcessorImpl: NewFreeswitchActivityProcessor(w, aP)}
+}
+
+func (p *AttendedTransferProcessor) Process(ctx workflow.Context, metadata shared.Metadata) (*shared.WorkflowOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(metadata.GetSessionId())
+
+	i := AttendedTransferInput{}
+	err := p.GetInput(metadata, &i)
+	if err != nil {
+		logger.Error("Failed to get input", zap.Error(err))
+		return output, err
+	}
+
+	result, decided := AttendedTransferComplete, false
+	output, _, err = AttendedTransfer(ctx, p.aP, i, func(ctx workflow.Context, timeout time.Duration) bool {
+		decided = true
+
+		s := workflow.NewSelector(ctx)
+		s.AddReceive(workflow.GetSignalChannel(ctx, AttendedTransferSignal), func(ch workflow.Channel, ok bool) {
+			ch.Receive(ctx, &result)
+		})
+		s.AddFuture(workflow.NewTimer(ctx, timeout), func(f workflow.Future) {})
+		s.Select(ctx)
+
+		return result != AttendedTransferCancel
+	})
+	if decided {
+		output.Metadata[shared.FieldMessage] = string(result)
+	}
+
+	return output, err
+}
+
+// AttendedTransfer parks the caller with music while the agent consults
+// Destination, then hands the caller over to the consult leg when decide,
+// given ConsultTimeout, reports true, or returns them to the agent. Every leg
+// gets park_after_bridge first, as each bridge swap ends the bridge a leg was
+// in and would otherwise hang up whoever is left in it. It reports whether the
+// transfer completed; the output is successful once the caller is bridged
+// again, to the consult leg or the agent.
+func AttendedTransfer(ctx workflow.Context, aP session.ActivityProvider, i AttendedTransferInput, decide func(ctx workflow.Context, timeout time.Duration) bool) (*shared.WorkflowOutput, bool, error) {
+	logger := workflow.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(i.SessionId)
+
+	if i.SessionId == "" {
+		return output, false, errors.RequireField("sessionId")
+	}
+
+	if i.AgentId == "" {
+		return output, false, errors.RequireField("agentId")
+	}
+
+	if i.Destination == "" {
+		return output, false, errors.RequireField("destination")
+	}
+
+	if i.Gateway == "" {
+		return output, false, errors.RequireField("gateway")
+	}
+
+	if i.Timeout == 0 {
+		i.Timeout = 30 * time.Second
+	}
+
+	if i.ConsultTimeout == 0 {
+		i.ConsultTimeout = 5 * time.Minute
+	}
+
+	t := &attendedTransfer{aP: aP, i: i, park: shared.HasChange(ctx, shared.ChangeTransferParkLegs)}
+
+	err := t.holdCaller(ctx)
+	if err != nil {
+		logger.Error("Failed to hold caller", zap.Error(err))
+		return output, false, err
+	}
+
+	oCtx := workflow.WithStartToCloseTimeout(ctx, i.Timeout)
+	oCtx = workflow.WithHeartbeatTimeout(oCtx, 10*time.Second)
+
+	oA := aP.GetActivity(activities.OriginateActivityName)
+	oOutput := shared.NewWorkflowOutput(i.SessionId)
+	err = workflow.ExecuteActivity(oCtx, oA.Handler(), activities.OriginateActivityInput{
+		WorkflowInput: shared.WorkflowInput{shared.FieldSessionId: i.SessionId},
+		Timeout:       i.Timeout,
+		Destination:   i.Destination,
+		Gateway:       i.Gateway,
+		Profile:       i.Profile,
+		Direction:     freeswitch.Outbound,
+	}).Get(oCtx, oOutput)
+
+	consult, _ := oOutput.Metadata.GetString(shared.FieldUniqueId)
+	if err != nil || !oOutput.Success || consult == "" {
+		logger.Error("Failed to originate consult leg", zap.Any("output", oOutput), zap.Error(err))
+		if rErr := t.returnCaller(ctx); rErr != nil {
+			logger.Error("Failed to return caller to agent", zap.Error(rErr))
+		}
+
+		return oOutput, false, err
+	}
+	output.Metadata[shared.FieldUniqueId] = consult
+
+	if t.park {
+		err = t.parkAfterBridge(ctx, consult)
+	}
+	if err == nil {
+		err = t.bridge(ctx, i.AgentId, consult)
+	}
+	if err != nil {
+		logger.Error("Failed to bridge agent with consult leg", zap.Error(err))
+		_ = t.hangup(ctx, consult, "AttendedTransferFailed")
+		_ = t.returnCaller(ctx)
+
+		return output, false, err
+	}
+
+	if !decide(ctx, i.ConsultTimeout) {
+		if t.park {
+			// The agent leaves the consult leg, parked, before it is hung up.
+			err = t.returnCaller(ctx)
+			if hErr := t.hangup(ctx, consult, "AttendedTransferCancelled"); hErr != nil {
+				logger.Error("Failed to hangup consult leg", zap.Error(hErr))
+			}
+		} else {
+			if hErr := t.hangup(ctx, consult, "AttendedTransferCancelled"); hErr != nil {
+				logger.Error("Failed to hangup consult leg", zap.Error(hErr))
+			}
+			err = t.releaseCaller(ctx)
+			if err == nil {
+				err = t.bridge(ctx, i.AgentId, i.SessionId)
+			}
+		}
+
+		output.Success = err == nil
+		return output, false, err
+	}
+
+	err = t.releaseCaller(ctx)
+	if err == nil {
+		err = t.bridge(ctx, i.SessionId, consult)
+	}
+
+	if err != nil {
+		logger.Error("Failed to bridge caller with consult leg", zap.Error(err))
+		return output, true, err
+	}
+
+	err = t.hangup(ctx, i.AgentId, "AttendedTransferCompleted")
+	if err != nil {
+		logger.Error("Failed to hangup agent", zap.Error(err))
+	}
+
+	output.Success = true
+
+	return output, true, nil
+}
+
+// defaultHoldMusic plays to the parked caller when no MOHFile is given.
+const defaultHoldMusic = "local_stream://moh"
+
+// attendedTransfer runs the steps of AttendedTransfer. Runs started before
+// ChangeTransferParkLegs only put the caller on hold, without parking any leg.
+type attendedTransfer struct {
+	aP   session.ActivityProvider
+	i    AttendedTransferInput
+	park bool
+}
+
+// holdCaller takes the caller away from the agent: with park_after_bridge on
+// both, parking the caller leaves the agent parked too, free to be bridged to
+// the consult leg.
+func (t *attendedTransfer) holdCaller(ctx workflow.Context) error {
+	if !t.park {
+		return t.hold(ctx, true)
+	}
+
+	for _, leg := range []string{t.i.SessionId, t.i.AgentId} {
+		if err := t.parkAfterBridge(ctx, leg); err != nil {
+			return err
+		}
+	}
+
+	pA := t.aP.GetActivity(activities.ParkActivityName)
+	err := workflow.ExecuteActivity(ctx, pA.Handler(), activities.ParkActivityInput{SessionId: t.i.SessionId}).Get(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return t.music(ctx, false)
+}
+
+// releaseCaller stops what holdCaller started, before the caller is bridged.
+func (t *attendedTransfer) releaseCaller(ctx workflow.Context) error {
+	if !t.park {
+		return t.hold(ctx, false)
+	}
+
+	return t.music(ctx, true)
+}
+
+// returnCaller gives the caller back to the agent before the agent was
+// bridged to the consult leg. Without parking they are still bridged, so
+// releasing the hold is all there is to do.
+func (t *attendedTransfer) returnCaller(ctx workflow.Context) error {
+	err := t.releaseCaller(ctx)
+	if err != nil || !t.park {
+		return err
+	}
+
+	return t.bridge(ctx, t.i.AgentId, t.i.SessionId)
+}
+
+func (t *attendedTransfer) parkAfterBridge(ctx workflow.Context, sessionId string) error {
+	vA := t.aP.GetActivity(activities.SetVariableActivityName)
+	return workflow.ExecuteActivity(ctx, vA.Handler(), activities.SetVariableActivityInput{
+		SessionId: sessionId,
+		Name:      "park_after_bridge",
+		Value:     "true",
+	}).Get(ctx, nil)
+}
+
+func (t *attendedTransfer) music(ctx workflow.Context, stop bool) error {
+	file := t.i.MOHFile
+	if file == "" {
+		file = defaultHoldMusic
+	}
+
+	dA := t.aP.GetActivity(activities.DisplaceActivityName)
+	return workflow.ExecuteActivity(ctx, dA.Handler(), activities.DisplaceActivityInput{
+		SessionId: t.i.SessionId,
+		File:      file,
+		Stop:      stop,
+	}).Get(ctx, nil)
+}
+
+func (t *attendedTransfer) hold(ctx workflow.Context, hold bool) error {
+	hA := t.aP.GetActivity(activities.HoldActivityName)
+	return workflow.ExecuteActivity(ctx, hA.Handler(), activities.HoldActivityInput{
+		SessionId: t.i.SessionId,
+		Hold:      hold,
+		MOHFile:   t.i.MOHFile,
+	}).Get(ctx, nil)
+}
+
+func (t *attendedTransfer) bridge(ctx workflow.Context, originator, originatee string) error {
+	bA := t.aP.GetActivity(activities.BridgeActivityName)
+	return workflow.ExecuteActivity(ctx, bA.Handler(), activities.BridgeActivityInput{
+		Originator: originator,
+		Originatee: originatee,
+	}).Get(ctx, nil)
+}
+
+func (t *attendedTransfer) hangup(ctx workflow.Context, sessionId, reason string) error {
+	hA := t.aP.GetActivity(activities.HangupActivityName)
+	return workflow.ExecuteActivity(ctx, hA.Handler(), activities.HangupActivityInput{
+		SessionId:    sessionId,
+		HangupCause:  string(shared.HangupNormalClearing),
+		HangupReason: reason,
+	}).Get(ctx, nil)
+}
+
+var _ shared.FreeswitchActivityProcessor = (*AttendedTransferProcessor)(nil)
@@ -0,0 +1,155 @@
+package processors
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+type MenuInput struct {
+	SessionId     string                     `json:"sessionId"`
+	MenuPrompt    string                     `json:"menuPrompt"`
+	InvalidPrompt string                     `json:"invalidPrompt"`
+	Options       map[string]shared.Metadata `json:"options"`
+	MaxTries      int                        `json:"maxTries"`
+	Timeout       time.Duration              `json:"timeout"`
+}
+
+// MenuProcessor collects a single digit and hands back the metadata bound to
+// it, so the selected action is dispatched next.
+type MenuProcessor struct {
+	*FreeswitchActivityProcessorImpl
+}
+
+func NewMenuProcessor(w shared.FreeswitchWorkflow, aP session.ActivityProvider) *MenuProcessor {
+	return &MenuProcessor{FreeswitchActivityProcessorImpl: NewFreeswitchActivityProcessor(w, aP)}
+}
+
+func (p *MenuProcessor) Process(ctx workflow.Context, metadata shared.Metadata) (*shared.WorkflowOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(metadata.GetSessionId())
+
+	i := MenuInput{}
+	err := p.GetInput(metadata, &i)
+	if err != nil {
+		logger.Error("Failed to get input", zap.Error(err))
+		return output, err
+	}
+
+	if i.SessionId == "" {
+		return output, errors.RequireField("sessionId")
+	}
+
+	if i.MenuPrompt == "" {
+		return output, errors.RequireField("menuPrompt")
+	}
+
+	if len(i.Options) == 0 {
+		return output, errors.RequireField("options")
+	}
+
+	if i.MaxTries <= 0 {
+		i.MaxTries = 3
+	}
+
+	if i.Timeout == 0 {
+		i.Timeout = 5 * time.Second
+	}
+
+	digit, err := CollectMenuDigit(ctx, p.aP, MenuCollect{
+		SessionId:     i.SessionId,
+		MenuPrompt:    i.MenuPrompt,
+		InvalidPrompt: i.InvalidPrompt,
+		MaxTries:      i.MaxTries,
+		Timeout:       i.Timeout,
+	}, func(digit string) bool {
+		next, ok := i.Options[digit]
+		return ok && next.GetAction() != shared.ActionUnknown
+	})
+	if err != nil {
+		return output, err
+	}
+
+	if digit != "" {
+		output.Success = true
+		output.Metadata = shared.Metadata{shared.FieldDigits: digit}
+		for k, v := range i.Options[digit] {
+			output.Metadata[k] = v
+		}
+
+		return output, nil
+	}
+
+	output.Metadata = shared.Metadata{
+		shared.FieldAction: string(shared.ActionHangup),
+		shared.FieldInput: map[string]interface{}{
+			"sessionId":    i.SessionId,
+			"hangupCause":  string(shared.HangupNormalClearing),
+			"hangupReason": "MenuMaxTriesExceeded",
+		},
+	}
+
+	return output, nil
+}
+
+// MenuCollect describes a single digit menu played to SessionId.
+type MenuCollect struct {
+	SessionId     string
+	MenuPrompt    string
+	InvalidPrompt string
+	MaxTries      int
+	Timeout       time.Duration
+}
+
+// CollectMenuDigit plays MenuPrompt and collects one digit until valid accepts
+// it, playing InvalidPrompt between tries. It returns "" without error once
+// MaxTries are used up.
+func CollectMenuDigit(ctx workflow.Context, aP session.ActivityProvider, m MenuCollect, valid func(digit string) bool) (string, error) {
+	logger := workflow.GetLogger(ctx)
+
+	cA := aP.GetActivity(activities.CollectDigitsActivityName)
+	pA := aP.GetActivity(activities.PlaybackActivityName)
+
+	for try := 1; try <= m.MaxTries; try++ {
+		cOutput := shared.NewWorkflowOutput(m.SessionId)
+		err := workflow.ExecuteActivity(ctx, cA.Handler(), activities.CollectDigitsActivityInput{
+			SessionId:  m.SessionId,
+			Min:        1,
+			Max:        1,
+			Tries:      1,
+			Timeout:    m.Timeout,
+			PromptFile: m.MenuPrompt,
+		}).Get(ctx, cOutput)
+
+		if err != nil {
+			logger.Error("Failed to execute CollectDigitsActivity", zap.Error(err))
+			return "", err
+		}
+
+		digit, _ := cOutput.Metadata.GetString(shared.FieldDigits)
+		if cOutput.Success && valid(digit) {
+			return digit, nil
+		}
+
+		logger.Warn("Invalid menu selection", zap.String("digit", digit), zap.Int("try", try))
+
+		if m.InvalidPrompt != "" && try < m.MaxTries {
+			err = workflow.ExecuteActivity(ctx, pA.Handler(), activities.PlaybackActivityInput{
+				SessionId: m.SessionId,
+				File:      m.InvalidPrompt,
+			}).Get(ctx, nil)
+
+			if err != nil {
+				logger.Error("Failed to execute PlaybackActivity", zap.Error(err))
+			}
+		}
+	}
+
+	return "", nil
+}
+
+var _ shared.FreeswitchActivityProcessor = (*MenuProcessor)(nil)
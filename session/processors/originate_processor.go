@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"github.com/luongdev/fsflow/errors"
 	"github.com/luongdev/fsflow/freeswitch"
 	"github.com/luongdev/fsflow/session"
 	"github.com/luongdev/fsflow/session/activities"
@@ -15,6 +16,8 @@ import (
 	"time"
 )
 
+const CallerHangupSignal = "caller_hangup"
+
 type OriginateProcessor struct {
 	*FreeswitchActivityProcessorImpl
 }
@@ -34,13 +37,62 @@ func (p *OriginateProcessor) Process(ctx workflow.Context, metadata shared.Metad
 		return output, err
 	}
 
-	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
-		StartToCloseTimeout:    i.Timeout,
-		ScheduleToStartTimeout: 1,
-	})
+	ctx = workflow.WithStartToCloseTimeout(ctx, i.Timeout)
+	ctx = workflow.WithHeartbeatTimeout(ctx, 10*time.Second)
+
+	// Runs started before the change leave the id to the activity.
+	if i.UniqueId == "" && shared.HasChange(ctx, shared.ChangeOriginateUniqueId) {
+		i.UniqueId, err = shared.WorkflowUUID(ctx)
+		if err != nil {
+			logger.Error("Failed to generate unique id", zap.Error(err))
+			return output, err
+		}
+	}
+
+	oCtx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
 
 	oA := p.aP.GetActivity(activities.OriginateActivityName)
-	err = workflow.ExecuteActivity(ctx, oA.Handler(), i).Get(ctx, &output)
+	f := workflow.ExecuteActivity(oCtx, oA.Handler(), i)
+
+	hungup := false
+	s := workflow.NewSelector(ctx)
+	s.AddFuture(f, func(f workflow.Future) {
+		err = f.Get(ctx, &output)
+	})
+	// Runs started before the change only waited for the originate.
+	if shared.HasChange(ctx, shared.ChangeOriginateCallerHangup) {
+		s.AddReceive(workflow.GetSignalChannel(ctx, CallerHangupSignal), func(ch workflow.Channel, ok bool) {
+			ch.Receive(ctx, nil)
+			hungup = true
+		})
+	}
+	s.Select(ctx)
+
+	if hungup {
+		logger.Info("Caller hung up while originating", zap.String("uniqueId", i.UniqueId))
+		cancel()
+
+		// A forked originate rings a leg per destination, each under its own uuid.
+		legs := []string{i.UniqueId}
+		if len(i.Destinations) > 0 {
+			legs = freeswitch.ForkLegIds(i.UniqueId, len(i.Destinations))
+		}
+
+		hA := p.aP.GetActivity(activities.HangupActivityName)
+		for _, leg := range legs {
+			err = workflow.ExecuteActivity(ctx, hA.Handler(), activities.HangupActivityInput{
+				SessionId:    leg,
+				HangupCause:  string(shared.HangupOriginatorCancel),
+				HangupReason: "CallerHangup",
+			}).Get(ctx, nil)
+			if err != nil {
+				logger.Error("Failed to hangup originated leg", zap.String("uniqueId", leg), zap.Error(err))
+			}
+		}
+
+		return output.WithSuccess(false).WithMessage("caller hung up"), nil
+	}
 
 	if err != nil {
 		logger.Error("Failed to execute originate activity", zap.Error(err))
@@ -49,20 +101,56 @@ func (p *OriginateProcessor) Process(ctx workflow.Context, metadata shared.Metad
 
 	if output.Success {
 		if !i.Background {
-			uid, ok := output.Metadata[shared.FieldUniqueId].(string)
-			if ok && uid != "" && i.Extension != "" && i.GetSessionId() != "" {
-				output.Metadata[shared.FieldAction] = shared.ActionBridge
+			// The activity leaves the caller on hold for the bridge to follow.
+			held := i.MOHDuringDial != "" && i.Extension != "" && i.GetSessionId() != "" &&
+				shared.HasChange(ctx, shared.ChangeOriginateReleaseHold)
+			release := func() {
+				if held {
+					held = false
+					p.releaseHold(ctx, i.GetSessionId())
+				}
+			}
+			defer release()
+
+			uid, ok := output.Metadata.GetString(shared.FieldUniqueId)
+			if i.Extension != "" && (!ok || uid == "") {
+				logger.Error("Originate returned no unique id", zap.Any("output", output))
+				return output, errors.RequireField(string(shared.FieldUniqueId))
+			}
+
+			if i.Extension != "" && i.GetSessionId() != "" && i.ConfirmPrompt != "" {
+				confirmed, err := ConfirmAnswer(ctx, p.aP, uid, i.ConfirmPrompt, i.ConfirmDigit)
+				if err != nil {
+					logger.Error("Failed to confirm answer", zap.String("uniqueId", uid), zap.Error(err))
+					return output, err
+				}
+
+				output.Metadata[shared.FieldAnswerConfirmed] = confirmed
+				if !confirmed {
+					return output.WithSuccess(false).WithMessage("answer not confirmed"), nil
+				}
+			}
+
+			if i.Extension != "" && i.GetSessionId() != "" {
+				release()
+
 				bInput := activities.BridgeActivityInput{
 					Originator:    i.GetSessionId(),
 					Originatee:    uid,
 					WorkflowInput: i.WorkflowInput,
 				}
-				output.Metadata[shared.FieldInput] = bInput
 
 				if i.Direction == freeswitch.Outbound {
 					bInput.Originatee = i.GetSessionId()
 					bInput.Originator = uid
 				}
+
+				bOutput, err := p.bridge(ctx, uid, bInput)
+				if err != nil || !bOutput.Success {
+					logger.Error("Failed to bridge originated leg", zap.String("uniqueId", uid), zap.Error(err))
+					return bOutput.WithSuccess(false), err
+				}
+				output.Metadata[shared.FieldMessage] = bOutput.Metadata[shared.FieldMessage]
 			}
 
 			go func() {
@@ -77,6 +165,52 @@ func (p *OriginateProcessor) Process(ctx workflow.Context, metadata shared.Metad
 	return output, nil
 }
 
+// bridge connects the originated leg, killing it when that fails so it does
+// not stay up, and billed, without an audio path.
+func (p *OriginateProcessor) bridge(ctx workflow.Context, uid string, i activities.BridgeActivityInput) (output *shared.WorkflowOutput, err error) {
+	logger := workflow.GetLogger(ctx)
+	output = shared.NewWorkflowOutput(i.GetSessionId())
+
+	// Runs started before the change never killed the leg.
+	kill := shared.HasChange(ctx, shared.ChangeOriginateKillLeg)
+
+	defer func() {
+		if !kill || (err == nil && output.Success) {
+			return
+		}
+
+		dCtx, cancel := workflow.NewDisconnectedContext(ctx)
+		defer cancel()
+
+		kA := p.aP.GetActivity(activities.KillActivityName)
+		kErr := workflow.ExecuteActivity(dCtx, kA.Handler(), activities.KillActivityInput{
+			SessionId: uid,
+			Cause:     string(shared.HangupNormalClearing),
+		}).Get(dCtx, nil)
+		if kErr != nil {
+			logger.Error("Failed to kill originated leg", zap.String("uniqueId", uid), zap.Error(kErr))
+		}
+	}()
+
+	bA := p.aP.GetActivity(activities.BridgeActivityName)
+	err = workflow.ExecuteActivity(ctx, bA.Handler(), i).Get(ctx, &output)
+
+	return output, err
+}
+
+// releaseHold takes the caller off the hold music OriginateActivity played
+// while dialing.
+func (p *OriginateProcessor) releaseHold(ctx workflow.Context, sessionId string) {
+	hA := p.aP.GetActivity(activities.HoldActivityName)
+	err := workflow.ExecuteActivity(ctx, hA.Handler(), activities.HoldActivityInput{
+		SessionId: sessionId,
+		Hold:      false,
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Failed to release caller hold", zap.String("sessionId", sessionId), zap.Error(err))
+	}
+}
+
 func (p *OriginateProcessor) sendCallback(url string, i interface{}) error {
 	bInput, err := json.Marshal(&i)
 	if err != nil {
@@ -0,0 +1,93 @@
+package processors
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+type PlaybackInput struct {
+	SessionId   string `json:"sessionId"`
+	File        string `json:"file"`
+	Loops       int    `json:"loops"`
+	Terminators string `json:"terminators"`
+	// NextAction is the metadata dispatched once the announcement is over,
+	// the session being hung up when it is empty.
+	NextAction shared.Metadata `json:"nextAction"`
+}
+
+// PlaybackProcessor plays an announcement and then routes the session on.
+type PlaybackProcessor struct {
+	*FreeswitchActivityProcessorImpl
+}
+
+func NewPlaybackProcessor(w shared.FreeswitchWorkflow, aP session.ActivityProvider) *PlaybackProcessor {
+	return &PlaybackProcessor{FreeswitchActivityProcessorImpl: NewFreeswitchActivityProcessor(w, aP)}
+}
+
+func (p *PlaybackProcessor) Process(ctx workflow.Context, metadata shared.Metadata) (*shared.WorkflowOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(metadata.GetSessionId())
+
+	i := PlaybackInput{}
+	err := p.GetInput(metadata, &i)
+	if err != nil {
+		logger.Error("Failed to get input", zap.Error(err))
+		return output, err
+	}
+
+	if i.SessionId == "" {
+		return output, errors.RequireField("sessionId")
+	}
+
+	if i.File == "" {
+		return output, errors.RequireField("file")
+	}
+
+	pA := p.aP.GetActivity(activities.PlaybackActivityName)
+	pOutput := shared.NewWorkflowOutput(i.SessionId)
+	err = workflow.ExecuteActivity(ctx, pA.Handler(), activities.PlaybackActivityInput{
+		SessionId:   i.SessionId,
+		File:        i.File,
+		Loops:       i.Loops,
+		Terminators: i.Terminators,
+	}).Get(ctx, pOutput)
+	if err != nil {
+		logger.Error("Failed to execute PlaybackActivity", zap.Error(err))
+		return output, err
+	}
+
+	output.Success = pOutput.Success
+	output.Metadata = shared.Metadata{}
+	for _, f := range []shared.Field{shared.FieldPlaybackResult, shared.FieldDigitPressed} {
+		if v, ok := pOutput.Metadata[f]; ok {
+			output.Metadata[f] = v
+		}
+	}
+
+	if r, _ := pOutput.Metadata.GetString(shared.FieldPlaybackResult); r == string(shared.PlaybackHangup) {
+		return output, nil
+	}
+
+	if i.NextAction.GetAction() != shared.ActionUnknown {
+		for k, v := range i.NextAction {
+			output.Metadata[k] = v
+		}
+
+		return output, nil
+	}
+
+	output.Metadata[shared.FieldAction] = string(shared.ActionHangup)
+	output.Metadata[shared.FieldInput] = map[string]interface{}{
+		"sessionId":    i.SessionId,
+		"hangupCause":  string(shared.HangupNormalClearing),
+		"hangupReason": "PlaybackCompleted",
+	}
+
+	return output, nil
+}
+
+var _ shared.FreeswitchActivityProcessor = (*PlaybackProcessor)(nil)
@@ -9,14 +9,29 @@ import (
 type FreeswitchProcessorFactoryImpl struct {
 	workflow shared.FreeswitchWorkflow
 
-	aP session.ActivityProvider
+	aP       session.ActivityProvider
+	registry *ProcessorRegistry
 }
 
 func NewFreeswitchProcessorFactory(w shared.FreeswitchWorkflow, aP session.ActivityProvider) *FreeswitchProcessorFactoryImpl {
-	return &FreeswitchProcessorFactoryImpl{workflow: w, aP: aP}
+	return &FreeswitchProcessorFactoryImpl{workflow: w, aP: aP, registry: NewProcessorRegistry()}
+}
+
+// Register adds or overrides the processor used for action by this factory
+// only, taking precedence over DefaultProcessorRegistry and the built-in ones.
+func (f *FreeswitchProcessorFactoryImpl) Register(action string, ctor ProcessorConstructor) {
+	f.registry.Register(action, ctor)
 }
 
 func (f *FreeswitchProcessorFactoryImpl) CreateActivityProcessor(s shared.Action) (shared.FreeswitchActivityProcessor, error) {
+	if ctor, ok := f.registry.Lookup(s); ok {
+		return ctor(f.workflow, f.aP), nil
+	}
+
+	if ctor, ok := DefaultProcessorRegistry.Lookup(s); ok {
+		return ctor(f.workflow, f.aP), nil
+	}
+
 	switch s {
 	case shared.ActionOriginate:
 		return NewOriginateProcessor(f.workflow, f.aP), nil
@@ -28,6 +43,14 @@ func (f *FreeswitchProcessorFactoryImpl) CreateActivityProcessor(s shared.Action
 		return NewHangupProcessor(f.workflow, f.aP), nil
 	case shared.ActionEvent:
 		return NewEventProcessor(f.workflow, f.aP), nil
+	case shared.ActionTransfer:
+		return NewTransferProcessor(f.workflow, f.aP), nil
+	case shared.ActionAttendedTransfer:
+		return NewAttendedTransferProcessor(f.workflow, f.aP), nil
+	case shared.ActionMenu:
+		return NewMenuProcessor(f.workflow, f.aP), nil
+	case shared.ActionPlayback:
+		return NewPlaybackProcessor(f.workflow, f.aP), nil
 
 	default:
 		return nil, errors.NewWorkflowInputError("unsupported action")
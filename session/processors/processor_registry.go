@@ -0,0 +1,42 @@
+package processors
+
+import (
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/shared"
+	"sync"
+)
+
+type ProcessorConstructor func(w shared.FreeswitchWorkflow, aP session.ActivityProvider) shared.FreeswitchActivityProcessor
+
+// ProcessorRegistry maps actions to processors supplied outside this package.
+type ProcessorRegistry struct {
+	mu    sync.RWMutex
+	ctors map[shared.Action]ProcessorConstructor
+}
+
+var DefaultProcessorRegistry = NewProcessorRegistry()
+
+func NewProcessorRegistry() *ProcessorRegistry {
+	return &ProcessorRegistry{ctors: make(map[shared.Action]ProcessorConstructor)}
+}
+
+func (r *ProcessorRegistry) Register(action string, ctor ProcessorConstructor) {
+	a := shared.RegisterAction(action)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ctors[a] = ctor
+}
+
+func (r *ProcessorRegistry) Lookup(action shared.Action) (ProcessorConstructor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctor, ok := r.ctors[action]
+	return ctor, ok && ctor != nil
+}
+
+func RegisterProcessor(action string, ctor ProcessorConstructor) {
+	DefaultProcessorRegistry.Register(action, ctor)
+}
@@ -0,0 +1,45 @@
+package processors
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+)
+
+type TransferProcessor struct {
+	*FreeswitchActivityProcessorImpl
+}
+
+func NewTransferProcessor(w shared.FreeswitchWorkflow, aP session.ActivityProvider) *TransferProcessor {
+	return &TransferProcessor{FreeswitchActivityProcessorImpl: NewFreeswitchActivityProcessor(w, aP)}
+}
+
+func (p *TransferProcessor) Process(ctx workflow.Context, metadata shared.Metadata) (*shared.WorkflowOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(metadata.GetSessionId())
+
+	i := activities.TransferActivityInput{}
+	err := p.GetInput(metadata, &i)
+	if err != nil {
+		logger.Error("Failed to get input", zap.Error(err))
+		return output, err
+	}
+
+	if i.SessionId == "" {
+		return output, errors.RequireField("sessionId")
+	}
+
+	if i.Extension == "" {
+		return output, errors.RequireField("extension")
+	}
+
+	tA := p.aP.GetActivity(activities.TransferActivityName)
+	err = workflow.ExecuteActivity(ctx, tA.Handler(), i).Get(ctx, &output)
+
+	return output, err
+}
+
+var _ shared.FreeswitchActivityProcessor = (*TransferProcessor)(nil)
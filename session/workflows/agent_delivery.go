@@ -0,0 +1,124 @@
+package workflows
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const defaultMOHFile = "local_stream://moh"
+
+type AgentDelivery struct {
+	SessionId string        `json:"sessionId"`
+	Endpoint  string        `json:"endpoint"`
+	Timeout   time.Duration `json:"timeout"`
+	MOHFile   string        `json:"mohFile"`
+}
+
+// ParkCaller parks the session with MOHFile playing over it, the state
+// DeliverToAgent expects the caller in.
+func ParkCaller(ctx workflow.Context, aP session.ActivityProvider, sessionId, mohFile string) error {
+	if mohFile == "" {
+		mohFile = defaultMOHFile
+	}
+
+	pA := aP.GetActivity(activities.ParkActivityName)
+	err := workflow.ExecuteActivity(ctx, pA.Handler(), activities.ParkActivityInput{SessionId: sessionId}).Get(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	dA := aP.GetActivity(activities.DisplaceActivityName)
+	return workflow.ExecuteActivity(ctx, dA.Handler(), activities.DisplaceActivityInput{
+		SessionId: sessionId,
+		File:      mohFile,
+	}).Get(ctx, nil)
+}
+
+// DeliverToAgent rings the agent endpoint and, once it answers, stops the
+// music of the caller parked by ParkCaller and bridges both. The caller stays
+// or is parked again when the agent does not answer or the bridge fails; the
+// output is then unsuccessful without error, so another agent can be tried.
+// ctx must carry activity options covering the ring timeout.
+func DeliverToAgent(ctx workflow.Context, aP session.ActivityProvider, d AgentDelivery) (*shared.WorkflowOutput, error) {
+	logger := workflow.GetLogger(ctx)
+	output := shared.NewWorkflowOutput(d.SessionId)
+
+	if d.SessionId == "" {
+		return output, errors.RequireField("sessionId")
+	}
+
+	if d.Endpoint == "" {
+		return output, errors.RequireField("endpoint")
+	}
+
+	if d.MOHFile == "" {
+		d.MOHFile = defaultMOHFile
+	}
+
+	oA := aP.GetActivity(activities.OriginateActivityName)
+	oOutput := shared.NewWorkflowOutput(d.SessionId)
+	err := workflow.ExecuteActivity(ctx, oA.Handler(), activities.OriginateActivityInput{
+		WorkflowInput: shared.WorkflowInput{shared.FieldSessionId: d.SessionId},
+		Timeout:       d.Timeout,
+		Endpoint:      d.Endpoint,
+		Direction:     freeswitch.Outbound,
+		AwaitAnswer:   true,
+	}).Get(ctx, oOutput)
+	if err != nil {
+		logger.Error("Failed to originate agent", zap.String("endpoint", d.Endpoint), zap.Error(err))
+		return output, err
+	}
+
+	uid, _ := oOutput.Metadata.GetString(shared.FieldUniqueId)
+	if !oOutput.Success || uid == "" {
+		logger.Info("Agent did not answer", zap.String("endpoint", d.Endpoint), zap.Any("output", oOutput))
+		output.Metadata[shared.FieldHangupCause] = oOutput.Metadata[shared.FieldHangupCause]
+		return output.WithMessage("agent did not answer"), nil
+	}
+
+	dA := aP.GetActivity(activities.DisplaceActivityName)
+	err = workflow.ExecuteActivity(ctx, dA.Handler(), activities.DisplaceActivityInput{
+		SessionId: d.SessionId,
+		File:      d.MOHFile,
+		Stop:      true,
+	}).Get(ctx, nil)
+	if err != nil {
+		logger.Warn("Failed to stop caller music on hold", zap.Error(err))
+	}
+
+	bA := aP.GetActivity(activities.BridgeActivityName)
+	bOutput := shared.NewWorkflowOutput(d.SessionId)
+	err = workflow.ExecuteActivity(ctx, bA.Handler(), activities.BridgeActivityInput{
+		Originator:    d.SessionId,
+		Originatee:    uid,
+		VerifyChannel: true,
+	}).Get(ctx, bOutput)
+	if err == nil && bOutput.Success {
+		output.Metadata[shared.FieldUniqueId] = uid
+		return output.WithSuccess(true), nil
+	}
+
+	logger.Warn("Failed to bridge agent, parking caller again", zap.String("uniqueId", uid), zap.Error(err))
+
+	kA := aP.GetActivity(activities.KillActivityName)
+	kErr := workflow.ExecuteActivity(ctx, kA.Handler(), activities.KillActivityInput{
+		SessionId: uid,
+		Cause:     string(shared.HangupNormalClearing),
+	}).Get(ctx, nil)
+	if kErr != nil {
+		logger.Error("Failed to kill agent leg", zap.String("uniqueId", uid), zap.Error(kErr))
+	}
+
+	if pErr := ParkCaller(ctx, aP, d.SessionId, d.MOHFile); pErr != nil {
+		logger.Error("Failed to park caller again", zap.Error(pErr))
+		return output, pErr
+	}
+
+	return output.WithMessage("agent could not be bridged"), nil
+}
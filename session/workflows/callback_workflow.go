@@ -0,0 +1,202 @@
+package workflows
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const CancelCallbackSignal = "cancel_callback"
+
+type CallbackWorkflowInput struct {
+	ANI           string            `json:"ani"`
+	Gateway       string            `json:"gateway"`
+	Domain        string            `json:"domain"`
+	Profile       string            `json:"profile"`
+	AgentEndpoint string            `json:"agentEndpoint"`
+	AgentGateway  string            `json:"agentGateway"`
+	ScheduledAt   time.Time         `json:"scheduledAt"`
+	Timeout       time.Duration     `json:"timeout"`
+	Payload       map[string]string `json:"payload"`
+}
+
+type CallbackWorkflow struct {
+	sP freeswitch.SocketProvider
+	aP session.ActivityProvider
+
+	r shared.WorkflowQueryResult
+	e error
+}
+
+const CallbackWorkflowName = "workflows.CallbackWorkflow"
+
+func (w *CallbackWorkflow) QueryResult(r shared.WorkflowQueryResult, e error) {
+	if r != nil {
+		if w.r == nil {
+			w.r = shared.WorkflowQueryResult{}
+		}
+		for k, v := range r {
+			w.r[k] = v
+		}
+	}
+
+	if e != nil {
+		w.e = e
+	}
+}
+
+func (w *CallbackWorkflow) SocketProvider() freeswitch.SocketProvider {
+	return w.sP
+}
+
+func (w *CallbackWorkflow) Name() string {
+	return CallbackWorkflowName
+}
+
+func NewCallbackWorkflow(sP freeswitch.SocketProvider, aP session.ActivityProvider) *CallbackWorkflow {
+	return &CallbackWorkflow{sP: sP, aP: aP}
+}
+
+func (w *CallbackWorkflow) Handler() shared.WorkflowFunc {
+	return func(ctx workflow.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := workflow.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		input := CallbackWorkflowInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to CallbackWorkflowInput")
+			return output, errors.NewWorkflowInputError("Cannot cast input to CallbackWorkflowInput")
+		}
+
+		if input.ANI == "" {
+			return output, errors.RequireField("ani")
+		}
+
+		if input.Gateway == "" {
+			return output, errors.RequireField("gateway")
+		}
+
+		if input.AgentEndpoint == "" {
+			return output, errors.RequireField("agentEndpoint")
+		}
+
+		if input.AgentGateway == "" {
+			input.AgentGateway = input.Gateway
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 30 * time.Second
+		}
+
+		if delay := input.ScheduledAt.Sub(workflow.Now(ctx)); delay > 0 {
+			tCtx, cancel := workflow.WithCancel(ctx)
+
+			cancelled := false
+			s := workflow.NewSelector(ctx)
+			s.AddFuture(workflow.NewTimer(tCtx, delay), func(f workflow.Future) {})
+			s.AddReceive(workflow.GetSignalChannel(ctx, CancelCallbackSignal), func(ch workflow.Channel, ok bool) {
+				ch.Receive(ctx, nil)
+				cancelled = true
+			})
+			s.Select(ctx)
+			cancel()
+
+			if cancelled {
+				logger.Info("Callback cancelled", zap.String("ani", input.ANI))
+				return output.WithMessage("callback cancelled"), nil
+			}
+		}
+
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			ScheduleToStartTimeout: time.Second,
+			StartToCloseTimeout:    input.Timeout + time.Minute,
+			HeartbeatTimeout:       10 * time.Second,
+		})
+
+		customer, err := w.originate(ctx, input, input.ANI, input.Gateway)
+		if err != nil || customer == "" {
+			logger.Error("Failed to reach customer", zap.String("ani", input.ANI), zap.Error(err))
+			return output.WithMessage("customer unreachable"), err
+		}
+		output.Metadata[shared.FieldUniqueId] = customer
+
+		agent, err := w.originate(ctx, input, input.AgentEndpoint, input.AgentGateway)
+		if err == nil && agent != "" {
+			bA := w.aP.GetActivity(activities.BridgeActivityName)
+			bOutput := shared.NewWorkflowOutput(customer)
+			err = workflow.ExecuteActivity(ctx, bA.Handler(), activities.BridgeActivityInput{
+				Originator: customer,
+				Originatee: agent,
+			}).Get(ctx, bOutput)
+
+			if err == nil && bOutput.Success {
+				return output.WithSuccess(true), nil
+			}
+		}
+
+		logger.Error("Failed to connect agent", zap.String("agent", input.AgentEndpoint), zap.Error(err))
+
+		// An answered agent would otherwise stay up, and billed, on its own.
+		if agent != "" && shared.HasChange(ctx, shared.ChangeCallbackKillAgent) {
+			kA := w.aP.GetActivity(activities.KillActivityName)
+			kErr := workflow.ExecuteActivity(ctx, kA.Handler(), activities.KillActivityInput{
+				SessionId: agent,
+				Cause:     string(shared.HangupNormalClearing),
+			}).Get(ctx, nil)
+			if kErr != nil {
+				logger.Error("Failed to kill agent leg", zap.String("agent", agent), zap.Error(kErr))
+			}
+		}
+
+		hA := w.aP.GetActivity(activities.HangupActivityName)
+		hErr := workflow.ExecuteActivity(ctx, hA.Handler(), activities.HangupActivityInput{
+			SessionId:    customer,
+			HangupCause:  string(shared.HangupNormalClearing),
+			HangupReason: "CallbackAgentUnavailable",
+		}).Get(ctx, nil)
+		if hErr != nil {
+			logger.Error("Failed to hangup customer", zap.Error(hErr))
+		}
+
+		return output.WithMessage("agent unavailable"), err
+	}
+}
+
+func (w *CallbackWorkflow) originate(ctx workflow.Context, input CallbackWorkflowInput, destination, gateway string) (string, error) {
+	variables := make(map[string]string, len(input.Payload)+1)
+	for k, v := range input.Payload {
+		variables[k] = v
+	}
+
+	if input.Domain != "" {
+		variables["domain_name"] = input.Domain
+	}
+
+	oA := w.aP.GetActivity(activities.OriginateActivityName)
+	oOutput := shared.NewWorkflowOutput("")
+	err := workflow.ExecuteActivity(ctx, oA.Handler(), activities.OriginateActivityInput{
+		WorkflowInput: shared.WorkflowInput{},
+		Timeout:       input.Timeout,
+		Destination:   destination,
+		Gateway:       gateway,
+		Profile:       input.Profile,
+		Direction:     freeswitch.Outbound,
+		Variables:     variables,
+	}).Get(ctx, oOutput)
+	if err != nil || !oOutput.Success {
+		return "", err
+	}
+
+	uid, _ := oOutput.Metadata.GetString(shared.FieldUniqueId)
+
+	return uid, nil
+}
+
+var _ shared.FreeswitchWorkflow = (*CallbackWorkflow)(nil)
@@ -0,0 +1,120 @@
+package workflows
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/processors"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	CompleteTransferSignal = "complete_transfer"
+	AbortTransferSignal    = "abort_transfer"
+)
+
+// ConsultAndTransferWorkflowInput is the input of the AttendedTransferProcessor,
+// both running processors.AttendedTransfer.
+type ConsultAndTransferWorkflowInput = processors.AttendedTransferInput
+
+// ConsultAndTransferWorkflow parks the caller while the agent talks to a third
+// party, then hands the caller over on CompleteTransferSignal or returns them
+// to the agent on AbortTransferSignal or once ConsultTimeout passes.
+type ConsultAndTransferWorkflow struct {
+	sP freeswitch.SocketProvider
+	aP session.ActivityProvider
+
+	r shared.WorkflowQueryResult
+	e error
+}
+
+const ConsultAndTransferWorkflowName = "workflows.ConsultAndTransferWorkflow"
+
+func (w *ConsultAndTransferWorkflow) QueryResult(r shared.WorkflowQueryResult, e error) {
+	if r != nil {
+		if w.r == nil {
+			w.r = shared.WorkflowQueryResult{}
+		}
+		for k, v := range r {
+			w.r[k] = v
+		}
+	}
+
+	if e != nil {
+		w.e = e
+	}
+}
+
+func (w *ConsultAndTransferWorkflow) SocketProvider() freeswitch.SocketProvider {
+	return w.sP
+}
+
+func (w *ConsultAndTransferWorkflow) Name() string {
+	return ConsultAndTransferWorkflowName
+}
+
+func NewConsultAndTransferWorkflow(sP freeswitch.SocketProvider, aP session.ActivityProvider) *ConsultAndTransferWorkflow {
+	return &ConsultAndTransferWorkflow{sP: sP, aP: aP}
+}
+
+func (w *ConsultAndTransferWorkflow) Handler() shared.WorkflowFunc {
+	return func(ctx workflow.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := workflow.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		input := ConsultAndTransferWorkflowInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to ConsultAndTransferWorkflowInput")
+			return output, errors.NewWorkflowInputError("Cannot cast input to ConsultAndTransferWorkflowInput")
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 30 * time.Second
+		}
+
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			ScheduleToStartTimeout: time.Second,
+			StartToCloseTimeout:    input.Timeout + time.Minute,
+			HeartbeatTimeout:       10 * time.Second,
+		})
+
+		decided := false
+		output, complete, err := processors.AttendedTransfer(ctx, w.aP, input, func(ctx workflow.Context, timeout time.Duration) bool {
+			decided = true
+
+			complete := false
+			s := workflow.NewSelector(ctx)
+			s.AddReceive(workflow.GetSignalChannel(ctx, CompleteTransferSignal), func(ch workflow.Channel, ok bool) {
+				ch.Receive(ctx, nil)
+				complete = true
+			})
+			s.AddReceive(workflow.GetSignalChannel(ctx, AbortTransferSignal), func(ch workflow.Channel, ok bool) {
+				ch.Receive(ctx, nil)
+			})
+			s.AddFuture(workflow.NewTimer(ctx, timeout), func(f workflow.Future) {})
+			s.Select(ctx)
+
+			return complete
+		})
+
+		switch {
+		case err != nil:
+			logger.Error("Consult transfer failed", zap.Error(err))
+		case !decided:
+			output.WithMessage("consult leg unreachable")
+		case complete:
+			output.WithMessage("transfer completed")
+		default:
+			output.WithMessage("transfer aborted")
+		}
+
+		return output, err
+	}
+}
+
+var _ shared.FreeswitchWorkflow = (*ConsultAndTransferWorkflow)(nil)
@@ -13,15 +13,21 @@ import (
 )
 
 type InboundWorkflowInput struct {
-	ANI         string        `json:"ani"`
-	DNIS        string        `json:"dnis"`
-	Domain      string        `json:"domain"`
+	ANI         string        `json:"ani" validate:"required"`
+	DNIS        string        `json:"dnis" validate:"required"`
+	Domain      string        `json:"domain" validate:"required"`
 	Initializer string        `json:"initializer"`
 	Timeout     time.Duration `json:"timeout"`
+
+	AwaitInit       bool                        `json:"awaitInit"`
+	CleanupOnCancel bool                        `json:"cleanupOnCancel"`
+	MOHDuringDial   string                      `json:"mohDuringDial"`
+	Retry           *shared.ActivityRetryConfig `json:"retry"`
 	shared.WorkflowInput
 }
 
 const InboundSignal = "inbound"
+const InitCompletedSignal = "init_completed"
 
 type InboundWorkflow struct {
 	sP freeswitch.SocketProvider
@@ -68,6 +74,14 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 			logger.Error("Failed to set query handler", zap.Error(err))
 		}
 
+		state := &shared.WorkflowState{Stage: shared.StageInitializing, SessionId: i.GetSessionId()}
+		err = workflow.SetQueryHandler(ctx, string(shared.QueryState), func() (shared.WorkflowState, error) {
+			return *state, nil
+		})
+		if err != nil {
+			logger.Error("Failed to set state query handler", zap.Error(err))
+		}
+
 		output := shared.NewWorkflowOutput(i.GetSessionId())
 		if err := i.Validate(); err != nil {
 			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
@@ -82,8 +96,16 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 			return output, errors.NewWorkflowInputError("Cannot cast input to InboundWorkflowInput")
 		}
 
-		ctx = workflow.WithActivityOptions(ctx,
-			workflow.ActivityOptions{ScheduleToStartTimeout: time.Second, StartToCloseTimeout: input.Timeout})
+		if err := shared.Validate(&input); err != nil {
+			logger.Error("Invalid input", zap.Any("input", input), zap.Error(err))
+			return output, err
+		}
+
+		ctx = workflow.WithActivityOptions(ctx, shared.NewActivityOptions(input.Timeout, input.Retry))
+
+		if input.CleanupOnCancel {
+			defer w.cleanup(ctx, i.GetSessionId())
+		}
 
 		si := w.aP.GetActivity("activities.SessionInitActivity")
 		f := workflow.ExecuteActivity(ctx, si.Handler(), activities.SessionInitActivityInput{
@@ -100,8 +122,20 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 			return output, err
 		}
 
+		if input.AwaitInit {
+			output.Metadata = w.awaitInit(ctx, i.GetSessionId(), input.Timeout)
+		}
+
+		carried := shared.Metadata{}
+		output.Metadata.CopyInto(carried)
+		delete(carried, shared.FieldAction)
+		delete(carried, shared.FieldInput)
+
 		processor := processors.NewFreeswitchActivityProcessor(w, w.aP)
+		w.withMOH(output.Metadata, input.MOHDuringDial)
+		w.track(state, output.Metadata, nil, nil)
 		output, err = processor.Process(ctx, output.Metadata)
+		w.track(state, nil, output, err)
 		if err != nil {
 			logger.Error("Failed to process metadata", zap.Any("metadata", output.Metadata), zap.Error(err))
 		}
@@ -109,6 +143,10 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 		r[shared.FieldAction] = output.Metadata.GetAction()
 		r[shared.FieldInput] = output.Metadata.GetInput()
 
+		watchHangup := shared.HasChange(ctx, shared.ChangeInboundCallerHangup)
+		carry := shared.HasChange(ctx, shared.ChangeInboundCarryMetadata)
+		watchCancel := shared.HasChange(ctx, shared.ChangeInboundCancellation)
+
 		m := shared.Metadata{}
 		signalChan := workflow.GetSignalChannel(ctx, InboundSignal)
 		for {
@@ -119,8 +157,34 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 				}
 			})
 
+			hungup := false
+			if watchHangup {
+				s.AddReceive(workflow.GetSignalChannel(ctx, processors.CallerHangupSignal), func(ch workflow.Channel, ok bool) {
+					ch.Receive(ctx, nil)
+					hungup = true
+				})
+			}
+
+			cancelled := false
+			if watchCancel {
+				s.AddReceive(ctx.Done(), func(ch workflow.Channel, ok bool) {
+					cancelled = true
+				})
+			}
+
 			s.Select(ctx)
 
+			if cancelled {
+				logger.Info("Workflow cancelled", zap.String("sessionId", i.GetSessionId()))
+				return output, ctx.Err()
+			}
+
+			if hungup {
+				logger.Info("Caller hung up", zap.String("sessionId", i.GetSessionId()))
+				state.Stage = shared.StageHungup
+				return output, nil
+			}
+
 			r[shared.FieldAction] = m.GetAction()
 			r[shared.FieldInput] = m.GetInput()
 
@@ -146,7 +210,13 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 				//}
 			}
 
+			if carry {
+				carried.CopyInto(m)
+			}
+			w.withMOH(m, input.MOHDuringDial)
+			w.track(state, m, nil, nil)
 			output, err := processor.Process(ctx, m)
+			w.track(state, nil, output, err)
 			if err != nil || !output.Success {
 				logger.Error("Failed to process metadata", zap.Any("metadata", output.Metadata), zap.Error(err))
 				//return output, err
@@ -159,4 +229,94 @@ func (w *InboundWorkflow) Handler() shared.WorkflowFunc {
 	}
 }
 
+// awaitInit waits for the initializer to send InitCompletedSignal with the
+// metadata to process, falling back to a NO_ANSWER hangup after timeout.
+func (w *InboundWorkflow) awaitInit(ctx workflow.Context, sessionId string, timeout time.Duration) shared.Metadata {
+	logger := workflow.GetLogger(ctx)
+
+	var m shared.Metadata
+	received := false
+
+	tCtx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	s := workflow.NewSelector(ctx)
+	s.AddReceive(workflow.GetSignalChannel(ctx, InitCompletedSignal), func(ch workflow.Channel, ok bool) {
+		ch.Receive(ctx, &m)
+		received = true
+	})
+	s.AddFuture(workflow.NewTimer(tCtx, timeout), func(f workflow.Future) {})
+	s.Select(ctx)
+
+	if received {
+		return m
+	}
+
+	logger.Warn("Initializer did not complete in time", zap.String("sessionId", sessionId), zap.Duration("timeout", timeout))
+
+	return shared.Metadata{
+		shared.FieldAction: string(shared.ActionHangup),
+		shared.FieldInput: map[string]interface{}{
+			"sessionId":    sessionId,
+			"hangupCause":  string(shared.HangupNoAnswer),
+			"hangupReason": "InitTimeout",
+		},
+	}
+}
+
+// withMOH sets the music played while an originate action dials, unless its
+// input brings its own.
+func (w *InboundWorkflow) withMOH(m shared.Metadata, file string) {
+	if file == "" || m.GetAction() != shared.ActionOriginate {
+		return
+	}
+
+	if in, ok := m[shared.FieldInput].(map[string]interface{}); ok {
+		if _, ok := in["mohDuringDial"]; !ok {
+			in["mohDuringDial"] = file
+		}
+	}
+}
+
+// track moves the queried state forward: before processing m it records the
+// stage of its action, afterwards the unique id and error of the result.
+func (w *InboundWorkflow) track(state *shared.WorkflowState, m shared.Metadata, o *shared.WorkflowOutput, err error) {
+	if stage, ok := shared.StageOf(m.GetAction()); ok {
+		state.Stage = stage
+	}
+
+	if o != nil {
+		if uid, ok := o.Metadata.GetString(shared.FieldUniqueId); ok && uid != "" {
+			state.Uid = uid
+		}
+	}
+
+	if err != nil {
+		state.LastError = err.Error()
+	}
+}
+
+// cleanup hangs the session up when the workflow has been cancelled. It runs on
+// a disconnected context because the workflow context is already cancelled.
+func (w *InboundWorkflow) cleanup(ctx workflow.Context, sessionId string) {
+	if ctx.Err() != workflow.ErrCanceled {
+		return
+	}
+
+	logger := workflow.GetLogger(ctx)
+	dCtx, cancel := workflow.NewDisconnectedContext(ctx)
+	defer cancel()
+
+	hA := w.aP.GetActivity(activities.HangupActivityName)
+	err := workflow.ExecuteActivity(dCtx, hA.Handler(), activities.HangupActivityInput{
+		SessionId:    sessionId,
+		HangupCause:  string(shared.HangupOriginatorCancel),
+		HangupReason: "WorkflowCancelled",
+	}).Get(dCtx, nil)
+
+	if err != nil {
+		logger.Error("Failed to hangup cancelled session", zap.String("sessionId", sessionId), zap.Error(err))
+	}
+}
+
 var _ shared.FreeswitchWorkflow = (*InboundWorkflow)(nil)
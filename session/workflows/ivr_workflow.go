@@ -0,0 +1,134 @@
+package workflows
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/session/processors"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+type IVRWorkflowInput struct {
+	SessionId     string            `json:"sessionId"`
+	MenuPrompt    string            `json:"menuPrompt"`
+	InvalidPrompt string            `json:"invalidPrompt"`
+	Options       map[string]string `json:"options"`
+	MaxTries      int               `json:"maxTries"`
+	Timeout       time.Duration     `json:"timeout"`
+}
+
+type IVRWorkflow struct {
+	sP freeswitch.SocketProvider
+	aP session.ActivityProvider
+
+	r shared.WorkflowQueryResult
+	e error
+}
+
+const IVRWorkflowName = "workflows.IVRWorkflow"
+
+func (w *IVRWorkflow) QueryResult(r shared.WorkflowQueryResult, e error) {
+	if r != nil {
+		if w.r == nil {
+			w.r = shared.WorkflowQueryResult{}
+		}
+		for k, v := range r {
+			w.r[k] = v
+		}
+	}
+
+	if e != nil {
+		w.e = e
+	}
+}
+
+func (w *IVRWorkflow) SocketProvider() freeswitch.SocketProvider {
+	return w.sP
+}
+
+func (w *IVRWorkflow) Name() string {
+	return IVRWorkflowName
+}
+
+func NewIVRWorkflow(sP freeswitch.SocketProvider, aP session.ActivityProvider) *IVRWorkflow {
+	return &IVRWorkflow{sP: sP, aP: aP}
+}
+
+func (w *IVRWorkflow) Handler() shared.WorkflowFunc {
+	return func(ctx workflow.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := workflow.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, err
+		}
+
+		input := IVRWorkflowInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to IVRWorkflowInput")
+			return output, errors.NewWorkflowInputError("Cannot cast input to IVRWorkflowInput")
+		}
+
+		if input.MenuPrompt == "" {
+			return output, errors.RequireField("menuPrompt")
+		}
+
+		if len(input.Options) == 0 {
+			return output, errors.RequireField("options")
+		}
+
+		if input.MaxTries <= 0 {
+			input.MaxTries = 3
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 5 * time.Second
+		}
+
+		ctx = workflow.WithActivityOptions(ctx,
+			workflow.ActivityOptions{ScheduleToStartTimeout: time.Second, StartToCloseTimeout: input.Timeout + time.Minute})
+
+		digit, err := processors.CollectMenuDigit(ctx, w.aP, processors.MenuCollect{
+			SessionId:     input.SessionId,
+			MenuPrompt:    input.MenuPrompt,
+			InvalidPrompt: input.InvalidPrompt,
+			MaxTries:      input.MaxTries,
+			Timeout:       input.Timeout,
+		}, func(digit string) bool {
+			_, ok := input.Options[digit]
+			return ok
+		})
+		if err != nil {
+			return output, err
+		}
+
+		if digit != "" {
+			output.Success = true
+			output.Metadata[shared.FieldAction] = input.Options[digit]
+			output.Metadata[shared.FieldDigits] = digit
+
+			return output, nil
+		}
+
+		hA := w.aP.GetActivity(activities.HangupActivityName)
+		err = workflow.ExecuteActivity(ctx, hA.Handler(), activities.HangupActivityInput{
+			SessionId:    input.SessionId,
+			HangupCause:  "NORMAL_CLEARING",
+			HangupReason: "IVRMaxTriesExceeded",
+		}).Get(ctx, output)
+
+		output.Success = false
+		output.Metadata[shared.FieldAction] = shared.ActionHangup
+
+		return output, err
+	}
+}
+
+var _ shared.FreeswitchWorkflow = (*IVRWorkflow)(nil)
@@ -0,0 +1,171 @@
+package workflows
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/session/processors"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+type OutboundWorkflowInput struct {
+	ANI         string        `json:"ani"`
+	Destination string        `json:"destination"`
+	Gateway     string        `json:"gateway"`
+	Domain      string        `json:"domain"`
+	Profile     string        `json:"profile"`
+	Timeout     time.Duration `json:"timeout"`
+
+	ConfirmPrompt string `json:"confirmPrompt"`
+	ConfirmDigit  string `json:"confirmDigit"`
+	shared.WorkflowInput
+}
+
+type OutboundWorkflow struct {
+	sP freeswitch.SocketProvider
+	aP session.ActivityProvider
+
+	r shared.WorkflowQueryResult
+	e error
+}
+
+const OutboundWorkflowName = "workflows.OutboundWorkflow"
+
+func (w *OutboundWorkflow) QueryResult(r shared.WorkflowQueryResult, e error) {
+	if r != nil {
+		if w.r == nil {
+			w.r = shared.WorkflowQueryResult{}
+		}
+		for k, v := range r {
+			w.r[k] = v
+		}
+	}
+
+	if e != nil {
+		w.e = e
+	}
+}
+
+func (w *OutboundWorkflow) SocketProvider() freeswitch.SocketProvider {
+	return w.sP
+}
+
+func (w *OutboundWorkflow) Name() string {
+	return OutboundWorkflowName
+}
+
+func NewOutboundWorkflow(sP freeswitch.SocketProvider, aP session.ActivityProvider) *OutboundWorkflow {
+	return &OutboundWorkflow{sP: sP, aP: aP}
+}
+
+func (w *OutboundWorkflow) Handler() shared.WorkflowFunc {
+	return func(ctx workflow.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := workflow.GetLogger(ctx)
+
+		r := shared.WorkflowQueryResult{}
+		err := workflow.SetQueryHandler(ctx, string(shared.QuerySession), func() (shared.WorkflowQueryResult, error) {
+			return r, w.e
+		})
+		if err != nil {
+			logger.Error("Failed to set query handler", zap.Error(err))
+		}
+
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		input := OutboundWorkflowInput{}
+		ok := shared.ConvertInput(i, &input)
+		if !ok {
+			logger.Error("Failed to cast input to OutboundWorkflowInput")
+			return output, errors.NewWorkflowInputError("Cannot cast input to OutboundWorkflowInput")
+		}
+
+		if input.Destination == "" {
+			return output, errors.RequireField("destination")
+		}
+
+		if input.Gateway == "" {
+			return output, errors.RequireField("gateway")
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 30 * time.Second
+		}
+
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			ScheduleToStartTimeout: time.Second,
+			StartToCloseTimeout:    input.Timeout,
+			HeartbeatTimeout:       10 * time.Second,
+		})
+
+		r[shared.FieldAction] = shared.ActionOriginate
+		oA := w.aP.GetActivity(activities.OriginateActivityName)
+		err = workflow.ExecuteActivity(ctx, oA.Handler(), activities.OriginateActivityInput{
+			WorkflowInput: input.WorkflowInput,
+			Timeout:       input.Timeout,
+			DialedNumber:  input.ANI,
+			Destination:   input.Destination,
+			Gateway:       input.Gateway,
+			Profile:       input.Profile,
+			Direction:     freeswitch.Outbound,
+			Domain:        input.Domain,
+		}).Get(ctx, output)
+
+		if err != nil || !output.Success {
+			logger.Error("Failed to execute OriginateActivity", zap.Any("output", output), zap.Error(err))
+			w.QueryResult(nil, err)
+			return output, err
+		}
+
+		uid, ok := output.Metadata.GetString(shared.FieldUniqueId)
+		if !ok || uid == "" {
+			logger.Error("OriginateActivity returned no unique id", zap.Any("output", output))
+			return output, errors.RequireField(string(shared.FieldUniqueId))
+		}
+		output.Metadata[shared.FieldUniqueId] = uid
+		r[shared.FieldUniqueId] = uid
+
+		sessionId := i.GetSessionId()
+		if sessionId == "" {
+			return output, nil
+		}
+
+		if input.ConfirmPrompt != "" {
+			confirmed, err := processors.ConfirmAnswer(ctx, w.aP, uid, input.ConfirmPrompt, input.ConfirmDigit)
+			if err != nil {
+				logger.Error("Failed to confirm answer", zap.String("uniqueId", uid), zap.Error(err))
+				return output, err
+			}
+
+			output.Metadata[shared.FieldAnswerConfirmed] = confirmed
+			if !confirmed {
+				return output.WithSuccess(false).WithMessage("answer not confirmed"), nil
+			}
+		}
+
+		r[shared.FieldAction] = shared.ActionBridge
+		bA := w.aP.GetActivity(activities.BridgeActivityName)
+		bOutput := shared.NewWorkflowOutput(sessionId)
+		err = workflow.ExecuteActivity(ctx, bA.Handler(), activities.BridgeActivityInput{
+			Originator:    uid,
+			Originatee:    sessionId,
+			WorkflowInput: input.WorkflowInput,
+		}).Get(ctx, bOutput)
+
+		if err != nil || !bOutput.Success {
+			logger.Error("Failed to execute BridgeActivity", zap.Any("output", bOutput), zap.Error(err))
+			w.QueryResult(nil, err)
+			output.Success = false
+			return output, err
+		}
+
+		output.Metadata[shared.FieldMessage] = bOutput.Metadata[shared.FieldMessage]
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchWorkflow = (*OutboundWorkflow)(nil)
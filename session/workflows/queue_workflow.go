@@ -0,0 +1,299 @@
+package workflows
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/session/processors"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	AgentAvailableSignal = "agent_available"
+	QueuePositionSignal  = "queue_position"
+
+	QueryQueue shared.Query = "queue"
+)
+
+type QueueWorkflowInput struct {
+	QueueName string        `json:"queueName"`
+	SessionId string        `json:"sessionId"`
+	MOHFile   string        `json:"mohFile"`
+	Timeout   time.Duration `json:"timeout"`
+}
+
+type AgentAvailable struct {
+	SessionId string        `json:"sessionId"`
+	Endpoint  string        `json:"endpoint"`
+	Gateway   string        `json:"gateway"`
+	Profile   string        `json:"profile"`
+	Timeout   time.Duration `json:"timeout"`
+}
+
+type QueueState struct {
+	QueueName string `json:"queueName"`
+	SessionId string `json:"sessionId"`
+	Position  int    `json:"position"`
+	AgentId   string `json:"agentId"`
+}
+
+type QueueWorkflow struct {
+	sP freeswitch.SocketProvider
+	aP session.ActivityProvider
+
+	r shared.WorkflowQueryResult
+	e error
+}
+
+const QueueWorkflowName = "workflows.QueueWorkflow"
+
+func (w *QueueWorkflow) QueryResult(r shared.WorkflowQueryResult, e error) {
+	if r != nil {
+		if w.r == nil {
+			w.r = shared.WorkflowQueryResult{}
+		}
+		for k, v := range r {
+			w.r[k] = v
+		}
+	}
+
+	if e != nil {
+		w.e = e
+	}
+}
+
+func (w *QueueWorkflow) SocketProvider() freeswitch.SocketProvider {
+	return w.sP
+}
+
+func (w *QueueWorkflow) Name() string {
+	return QueueWorkflowName
+}
+
+func NewQueueWorkflow(sP freeswitch.SocketProvider, aP session.ActivityProvider) *QueueWorkflow {
+	return &QueueWorkflow{sP: sP, aP: aP}
+}
+
+func (w *QueueWorkflow) Handler() shared.WorkflowFunc {
+	return func(ctx workflow.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := workflow.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, err
+		}
+
+		input := QueueWorkflowInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to QueueWorkflowInput")
+			return output, errors.NewWorkflowInputError("Cannot cast input to QueueWorkflowInput")
+		}
+
+		if input.QueueName == "" {
+			return output, errors.RequireField("queueName")
+		}
+
+		if input.SessionId == "" {
+			return output, errors.RequireField("sessionId")
+		}
+
+		if input.MOHFile == "" {
+			input.MOHFile = "local_stream://moh"
+		}
+
+		if input.Timeout == 0 {
+			input.Timeout = 10 * time.Minute
+		}
+
+		state := &QueueState{QueueName: input.QueueName, SessionId: input.SessionId}
+		err := workflow.SetQueryHandler(ctx, string(QueryQueue), func() (QueueState, error) {
+			return *state, nil
+		})
+		if err != nil {
+			logger.Error("Failed to set query handler", zap.Error(err))
+		}
+
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			ScheduleToStartTimeout: time.Second,
+			StartToCloseTimeout:    input.Timeout,
+			HeartbeatTimeout:       10 * time.Second,
+		})
+
+		aA := w.aP.GetActivity(activities.AnswerActivityName)
+		err = workflow.ExecuteActivity(ctx, aA.Handler(), activities.AnswerActivityInput{
+			SessionId: input.SessionId,
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to execute AnswerActivity", zap.Error(err))
+			return output, err
+		}
+
+		agentChan := workflow.GetSignalChannel(ctx, AgentAvailableSignal)
+		positionChan := workflow.GetSignalChannel(ctx, QueuePositionSignal)
+		hangupChan := workflow.GetSignalChannel(ctx, processors.CallerHangupSignal)
+		timeout := workflow.NewTimer(ctx, input.Timeout)
+
+		pA := w.aP.GetActivity(activities.PlaybackActivityName)
+		for {
+			pCtx, cancel := workflow.WithCancel(ctx)
+			playback := workflow.ExecuteActivity(pCtx, pA.Handler(), activities.PlaybackActivityInput{
+				SessionId: input.SessionId,
+				File:      input.MOHFile,
+			})
+
+			var agent *AgentAvailable
+			hungup, timedOut := false, false
+			var playErr error
+			for agent == nil && !hungup && !timedOut {
+				playing := true
+
+				s := workflow.NewSelector(ctx)
+				s.AddFuture(playback, func(f workflow.Future) {
+					playing = false
+					playErr = f.Get(ctx, nil)
+				})
+				s.AddReceive(agentChan, func(ch workflow.Channel, ok bool) {
+					agent = &AgentAvailable{}
+					ch.Receive(ctx, agent)
+				})
+				s.AddReceive(positionChan, func(ch workflow.Channel, ok bool) {
+					ch.Receive(ctx, &state.Position)
+				})
+				s.AddReceive(hangupChan, func(ch workflow.Channel, ok bool) {
+					ch.Receive(ctx, nil)
+					hungup = true
+				})
+				s.AddFuture(timeout, func(f workflow.Future) {
+					timedOut = true
+				})
+				s.Select(ctx)
+
+				if !playing {
+					break
+				}
+			}
+			cancel()
+
+			if hungup {
+				logger.Info("Caller hung up while queued", zap.String("queue", input.QueueName))
+				return output.WithMessage("caller hung up"), nil
+			}
+
+			if timedOut {
+				logger.Warn("Queue wait timed out", zap.String("queue", input.QueueName))
+
+				hA := w.aP.GetActivity(activities.HangupActivityName)
+				err = workflow.ExecuteActivity(ctx, hA.Handler(), activities.HangupActivityInput{
+					SessionId:    input.SessionId,
+					HangupCause:  string(shared.HangupNormalClearing),
+					HangupReason: "QueueTimeout",
+				}).Get(ctx, nil)
+
+				output.Metadata[shared.FieldAction] = shared.ActionHangup
+				return output.WithMessage("queue timeout"), err
+			}
+
+			if agent == nil {
+				if playErr != nil {
+					logger.Error("Failed to play music on hold", zap.Error(playErr))
+					return output, playErr
+				}
+				continue
+			}
+
+			uid, err := w.connect(ctx, input, agent)
+			if err != nil {
+				logger.Warn("Failed to connect agent, waiting for another", zap.Any("agent", agent), zap.Error(err))
+				continue
+			}
+
+			state.AgentId = uid
+			output.Metadata[shared.FieldUniqueId] = uid
+
+			return output.WithSuccess(true), nil
+		}
+	}
+}
+
+// connect bridges the caller to the agent, dialing the agent's endpoint first
+// when the signal did not carry an existing agent session. The caller keeps
+// hearing MOHFile while the agent rings, and a dialed agent is killed again
+// when the bridge fails.
+func (w *QueueWorkflow) connect(ctx workflow.Context, input QueueWorkflowInput, agent *AgentAvailable) (string, error) {
+	logger := workflow.GetLogger(ctx)
+	fixed := shared.HasChange(ctx, shared.ChangeQueueConnectAgent)
+
+	uid, dialed := agent.SessionId, false
+	if uid == "" {
+		if agent.Endpoint == "" || agent.Gateway == "" {
+			return "", errors.RequireField("endpoint")
+		}
+
+		if fixed {
+			pCtx, cancel := workflow.WithCancel(ctx)
+			defer cancel()
+
+			pA := w.aP.GetActivity(activities.PlaybackActivityName)
+			workflow.ExecuteActivity(pCtx, pA.Handler(), activities.PlaybackActivityInput{
+				SessionId: input.SessionId,
+				File:      input.MOHFile,
+			})
+		}
+
+		oA := w.aP.GetActivity(activities.OriginateActivityName)
+		oOutput := shared.NewWorkflowOutput(input.SessionId)
+		err := workflow.ExecuteActivity(ctx, oA.Handler(), activities.OriginateActivityInput{
+			WorkflowInput: shared.WorkflowInput{shared.FieldSessionId: input.SessionId},
+			Timeout:       agent.Timeout,
+			Destination:   agent.Endpoint,
+			Gateway:       agent.Gateway,
+			Profile:       agent.Profile,
+			Direction:     freeswitch.Outbound,
+		}).Get(ctx, oOutput)
+		if err != nil {
+			return "", err
+		}
+
+		uid, _ = oOutput.Metadata.GetString(shared.FieldUniqueId)
+		if !oOutput.Success || uid == "" {
+			return "", errors.RequireField(string(shared.FieldUniqueId))
+		}
+		dialed = true
+	}
+
+	bA := w.aP.GetActivity(activities.BridgeActivityName)
+	bOutput := shared.NewWorkflowOutput(input.SessionId)
+	err := workflow.ExecuteActivity(ctx, bA.Handler(), activities.BridgeActivityInput{
+		Originator:    input.SessionId,
+		Originatee:    uid,
+		VerifyChannel: true,
+	}).Get(ctx, bOutput)
+	if err == nil && !bOutput.Success {
+		err = errors.NewWorkflowInputError("agent channel no longer exists")
+	}
+
+	if err != nil {
+		if dialed && fixed {
+			kA := w.aP.GetActivity(activities.KillActivityName)
+			kErr := workflow.ExecuteActivity(ctx, kA.Handler(), activities.KillActivityInput{
+				SessionId: uid,
+				Cause:     string(shared.HangupNormalClearing),
+			}).Get(ctx, nil)
+			if kErr != nil {
+				logger.Error("Failed to kill agent leg", zap.String("uniqueId", uid), zap.Error(kErr))
+			}
+		}
+		return "", err
+	}
+
+	return uid, nil
+}
+
+var _ shared.FreeswitchWorkflow = (*QueueWorkflow)(nil)
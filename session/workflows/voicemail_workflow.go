@@ -0,0 +1,181 @@
+package workflows
+
+import (
+	"fmt"
+	"github.com/luongdev/fsflow/errors"
+	"github.com/luongdev/fsflow/freeswitch"
+	"github.com/luongdev/fsflow/session"
+	"github.com/luongdev/fsflow/session/activities"
+	"github.com/luongdev/fsflow/shared"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	voicemailDir        = "/var/lib/freeswitch/storage/voicemail"
+	voicemailTerminator = "#"
+)
+
+type VoicemailWorkflowInput struct {
+	SessionId         string `json:"sessionId"`
+	GreetingFile      string `json:"greetingFile"`
+	MaxMessageSeconds int    `json:"maxMessageSeconds"`
+	Mailbox           string `json:"mailbox"`
+	Domain            string `json:"domain"`
+}
+
+type VoicemailWorkflow struct {
+	sP freeswitch.SocketProvider
+	aP session.ActivityProvider
+
+	r shared.WorkflowQueryResult
+	e error
+}
+
+const VoicemailWorkflowName = "workflows.VoicemailWorkflow"
+
+func (w *VoicemailWorkflow) QueryResult(r shared.WorkflowQueryResult, e error) {
+	if r != nil {
+		if w.r == nil {
+			w.r = shared.WorkflowQueryResult{}
+		}
+		for k, v := range r {
+			w.r[k] = v
+		}
+	}
+
+	if e != nil {
+		w.e = e
+	}
+}
+
+func (w *VoicemailWorkflow) SocketProvider() freeswitch.SocketProvider {
+	return w.sP
+}
+
+func (w *VoicemailWorkflow) Name() string {
+	return VoicemailWorkflowName
+}
+
+func NewVoicemailWorkflow(sP freeswitch.SocketProvider, aP session.ActivityProvider) *VoicemailWorkflow {
+	return &VoicemailWorkflow{sP: sP, aP: aP}
+}
+
+func (w *VoicemailWorkflow) Handler() shared.WorkflowFunc {
+	return func(ctx workflow.Context, i shared.WorkflowInput) (*shared.WorkflowOutput, error) {
+		logger := workflow.GetLogger(ctx)
+		output := shared.NewWorkflowOutput(i.GetSessionId())
+
+		if err := i.Validate(); err != nil {
+			logger.Error("Invalid input", zap.Any("input", i), zap.Error(err))
+			return output, err
+		}
+
+		input := VoicemailWorkflowInput{}
+		ok := shared.ConvertInput(i, &input)
+
+		if !ok {
+			logger.Error("Failed to cast input to VoicemailWorkflowInput")
+			return output, errors.NewWorkflowInputError("Cannot cast input to VoicemailWorkflowInput")
+		}
+
+		if input.Mailbox == "" {
+			return output, errors.RequireField("mailbox")
+		}
+
+		if input.GreetingFile == "" {
+			return output, errors.RequireField("greetingFile")
+		}
+
+		if input.Domain == "" {
+			input.Domain = "default"
+		}
+
+		if input.MaxMessageSeconds <= 0 {
+			input.MaxMessageSeconds = 120
+		}
+
+		maxMessage := time.Duration(input.MaxMessageSeconds) * time.Second
+		ctx = workflow.WithActivityOptions(ctx,
+			workflow.ActivityOptions{ScheduleToStartTimeout: time.Second, StartToCloseTimeout: maxMessage + time.Minute})
+
+		aA := w.aP.GetActivity(activities.AnswerActivityName)
+		err := workflow.ExecuteActivity(ctx, aA.Handler(), activities.AnswerActivityInput{
+			SessionId: input.SessionId,
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to execute AnswerActivity", zap.Error(err))
+			return output, err
+		}
+
+		pA := w.aP.GetActivity(activities.PlaybackActivityName)
+		err = workflow.ExecuteActivity(ctx, pA.Handler(), activities.PlaybackActivityInput{
+			SessionId:   input.SessionId,
+			File:        input.GreetingFile,
+			Terminators: voicemailTerminator,
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to execute PlaybackActivity", zap.Error(err))
+			return output, err
+		}
+
+		messageId := input.SessionId
+		if shared.HasChange(ctx, shared.ChangeVoicemailMessageId) {
+			messageId, err = shared.WorkflowUUID(ctx)
+			if err != nil {
+				logger.Error("Failed to generate message id", zap.Error(err))
+				return output, err
+			}
+		}
+
+		path := fmt.Sprintf("%v/%v/%v/msg_%v.wav", voicemailDir, input.Domain, input.Mailbox, messageId)
+		rA := w.aP.GetActivity(activities.RecordSessionActivityName)
+		err = workflow.ExecuteActivity(ctx, rA.Handler(), activities.RecordSessionActivityInput{
+			SessionId:          input.SessionId,
+			Path:               path,
+			MaxDurationSeconds: input.MaxMessageSeconds,
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to start recording", zap.Error(err))
+			return output, err
+		}
+
+		// Silence keeps the channel busy until the caller presses the
+		// terminator or the message reaches its maximum duration.
+		err = workflow.ExecuteActivity(ctx, pA.Handler(), activities.PlaybackActivityInput{
+			SessionId:   input.SessionId,
+			File:        fmt.Sprintf("silence_stream://%v", maxMessage.Milliseconds()),
+			Terminators: voicemailTerminator,
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Warn("Recording wait interrupted", zap.Error(err))
+		}
+
+		err = workflow.ExecuteActivity(ctx, rA.Handler(), activities.RecordSessionActivityInput{
+			SessionId: input.SessionId,
+			Path:      path,
+			Stop:      true,
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to stop recording", zap.Error(err))
+		}
+
+		output.Success = true
+		output.Metadata[shared.FieldRecordingPath] = path
+
+		hA := w.aP.GetActivity(activities.HangupActivityName)
+		err = workflow.ExecuteActivity(ctx, hA.Handler(), activities.HangupActivityInput{
+			SessionId:    input.SessionId,
+			HangupCause:  string(shared.HangupNormalClearing),
+			HangupReason: "VoicemailRecorded",
+		}).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to execute HangupActivity", zap.Error(err))
+		}
+
+		return output, nil
+	}
+}
+
+var _ shared.FreeswitchWorkflow = (*VoicemailWorkflow)(nil)
@@ -0,0 +1,192 @@
+package shared
+
+import (
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+type BreakerConfig struct {
+	Threshold int           `json:"threshold"`
+	Window    time.Duration `json:"window"`
+	Cooldown  time.Duration `json:"cooldown"`
+	Clock     Clock         `json:"-"`
+}
+
+var DefaultBreakerConfig = BreakerConfig{
+	Threshold: 5,
+	Window:    time.Minute,
+	Cooldown:  30 * time.Second,
+}
+
+type breakerEntry struct {
+	state    BreakerState
+	failures int
+	first    time.Time
+	openedAt time.Time
+	probing  bool
+}
+
+// CircuitBreaker trips a key after Threshold consecutive failures within
+// Window. Once Cooldown has passed a single trial call is let through, its
+// outcome closing the breaker again or reopening it.
+type CircuitBreaker struct {
+	mu      sync.Mutex
+	config  BreakerConfig
+	entries map[string]*breakerEntry
+}
+
+func NewCircuitBreaker(c BreakerConfig) *CircuitBreaker {
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultBreakerConfig.Threshold
+	}
+
+	if c.Window <= 0 {
+		c.Window = DefaultBreakerConfig.Window
+	}
+
+	if c.Cooldown <= 0 {
+		c.Cooldown = DefaultBreakerConfig.Cooldown
+	}
+
+	if c.Clock == nil {
+		c.Clock = SystemClock
+	}
+
+	return &CircuitBreaker{config: c, entries: make(map[string]*breakerEntry)}
+}
+
+func (b *CircuitBreaker) entry(key string) *breakerEntry {
+	e, ok := b.entries[key]
+	if !ok {
+		e = &breakerEntry{state: BreakerClosed}
+		b.entries[key] = e
+	}
+
+	return e
+}
+
+func (b *CircuitBreaker) Allow(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := b.entry(key)
+	switch e.state {
+	case BreakerOpen:
+		if b.config.Clock.Now().Sub(e.openedAt) < b.config.Cooldown {
+			return false
+		}
+		e.state = BreakerHalfOpen
+		e.probing = true
+
+		return true
+	case BreakerHalfOpen:
+		if e.probing {
+			return false
+		}
+		e.probing = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) Success(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[key] = &breakerEntry{state: BreakerClosed}
+}
+
+func (b *CircuitBreaker) Failure(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := b.entry(key)
+	now := b.config.Clock.Now()
+
+	if e.state == BreakerHalfOpen {
+		e.state = BreakerOpen
+		e.openedAt = now
+		e.probing = false
+
+		return
+	}
+
+	if e.failures == 0 || now.Sub(e.first) > b.config.Window {
+		e.failures = 0
+		e.first = now
+	}
+
+	e.failures++
+	if e.failures >= b.config.Threshold {
+		e.state = BreakerOpen
+		e.openedAt = now
+	}
+}
+
+// Release hands back a trial call whose outcome says nothing about the key,
+// letting the next caller probe instead.
+func (b *CircuitBreaker) Release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.entries[key]; ok {
+		e.probing = false
+	}
+}
+
+func (b *CircuitBreaker) State(key string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.entries[key]; ok {
+		return e.state
+	}
+
+	return BreakerClosed
+}
+
+func (b *CircuitBreaker) States() map[string]BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]BreakerState, len(b.entries))
+	for k, e := range b.entries {
+		states[k] = e.state
+	}
+
+	return states
+}
+
+var (
+	breakerMu      sync.RWMutex
+	gatewayBreaker = NewCircuitBreaker(DefaultBreakerConfig)
+)
+
+// GatewayBreaker is the breaker OriginateActivity consults before dialing a
+// gateway.
+func GatewayBreaker() *CircuitBreaker {
+	breakerMu.RLock()
+	defer breakerMu.RUnlock()
+
+	return gatewayBreaker
+}
+
+func SetGatewayBreaker(b *CircuitBreaker) {
+	breakerMu.Lock()
+	defer breakerMu.Unlock()
+
+	if b == nil {
+		b = NewCircuitBreaker(DefaultBreakerConfig)
+	}
+	gatewayBreaker = b
+}
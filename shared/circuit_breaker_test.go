@@ -0,0 +1,91 @@
+package shared_test
+
+import (
+	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/shared/sharedtest"
+	"testing"
+	"time"
+)
+
+func trippedBreaker(clock *sharedtest.Clock) *shared.CircuitBreaker {
+	b := shared.NewCircuitBreaker(shared.BreakerConfig{
+		Threshold: 2,
+		Window:    time.Minute,
+		Cooldown:  30 * time.Second,
+		Clock:     clock,
+	})
+	b.Failure("gw")
+	b.Failure("gw")
+
+	return b
+}
+
+func TestCircuitBreakerCooldown(t *testing.T) {
+	clock := sharedtest.NewClock(time.Unix(0, 0))
+	b := trippedBreaker(clock)
+
+	if s := b.State("gw"); s != shared.BreakerOpen {
+		t.Fatalf("expected the breaker open, got %v", s)
+	}
+
+	clock.Advance(29 * time.Second)
+	if b.Allow("gw") {
+		t.Fatal("expected calls refused during the cooldown")
+	}
+
+	clock.Advance(time.Second)
+	if !b.Allow("gw") {
+		t.Fatal("expected a trial call once the cooldown passed")
+	}
+	if s := b.State("gw"); s != shared.BreakerHalfOpen {
+		t.Fatalf("expected the breaker half open, got %v", s)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	clock := sharedtest.NewClock(time.Unix(0, 0))
+
+	b := trippedBreaker(clock)
+	clock.Advance(30 * time.Second)
+	if !b.Allow("gw") {
+		t.Fatal("expected a trial call")
+	}
+	if b.Allow("gw") {
+		t.Fatal("expected a single trial call at a time")
+	}
+
+	b.Success("gw")
+	if s := b.State("gw"); s != shared.BreakerClosed {
+		t.Fatalf("expected a successful trial to close the breaker, got %v", s)
+	}
+
+	b = trippedBreaker(clock)
+	clock.Advance(30 * time.Second)
+	b.Allow("gw")
+	b.Failure("gw")
+	if s := b.State("gw"); s != shared.BreakerOpen {
+		t.Fatalf("expected a failed trial to reopen the breaker, got %v", s)
+	}
+	if b.Allow("gw") {
+		t.Fatal("expected the reopened breaker to cool down again")
+	}
+
+	clock.Advance(30 * time.Second)
+	b.Allow("gw")
+	b.Release("gw")
+	if !b.Allow("gw") {
+		t.Fatal("expected a released trial to let the next caller probe")
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	clock := sharedtest.NewClock(time.Unix(0, 0))
+	b := shared.NewCircuitBreaker(shared.BreakerConfig{Threshold: 2, Window: time.Minute, Clock: clock})
+
+	b.Failure("gw")
+	clock.Advance(2 * time.Minute)
+	b.Failure("gw")
+	if s := b.State("gw"); s != shared.BreakerClosed {
+		t.Fatalf("expected failures outside the window not to trip, got %v", s)
+	}
+}
@@ -6,20 +6,26 @@ import (
 	"fmt"
 	"github.com/luongdev/fsflow/errors"
 	"go.uber.org/cadence/workflow"
+	"math"
+	"sync"
+	"time"
 )
 
 type Action string
 
 const (
-	ActionAnswer    Action = "answer"
-	ActionBridge    Action = "bridge"
-	ActionCallback  Action = "callback"
-	ActionEvent     Action = "event"
-	ActionHangup    Action = "hangup"
-	ActionTransfer  Action = "transfer"
-	ActionOriginate Action = "originate"
-	ActionSet       Action = "set"
-	ActionUnknown   Action = "unknown"
+	ActionAnswer           Action = "answer"
+	ActionBridge           Action = "bridge"
+	ActionCallback         Action = "callback"
+	ActionEvent            Action = "event"
+	ActionHangup           Action = "hangup"
+	ActionTransfer         Action = "transfer"
+	ActionAttendedTransfer Action = "attended_transfer"
+	ActionOriginate        Action = "originate"
+	ActionMenu             Action = "menu"
+	ActionPlayback         Action = "playback"
+	ActionSet              Action = "set"
+	ActionUnknown          Action = "unknown"
 )
 
 type Field string
@@ -32,31 +38,124 @@ const (
 	FieldInput     Field = "input"
 	FieldOutput    Field = "output"
 	FieldUniqueId  Field = "uniqueId"
+
+	FieldDigitPressed        Field = "digitPressed"
+	FieldDigits              Field = "digits"
+	FieldRecordingPath       Field = "recordingPath"
+	FieldUsedGateway         Field = "usedGateway"
+	FieldMemberId            Field = "memberId"
+	FieldHangupCause         Field = "hangupCause"
+	FieldOnHold              Field = "onHold"
+	FieldAnsweredDestination Field = "answeredDestination"
+	FieldMuted               Field = "muted"
+	FieldMediaState          Field = "mediaState"
+	FieldSipStatus           Field = "sipStatus"
+	FieldPlaybackResult      Field = "playbackResult"
+	FieldRecordingStatus     Field = "recordingStatus"
+	FieldHungupSessions      Field = "hungupSessions"
+	FieldAnswerConfirmed     Field = "answerConfirmed"
+)
+
+type PlaybackResult string
+
+const (
+	PlaybackCompleted   PlaybackResult = "completed"
+	PlaybackInterrupted PlaybackResult = "interrupted"
+	PlaybackHangup      PlaybackResult = "hangup"
+)
+
+type MediaState string
+
+const (
+	MediaEarly    MediaState = "early"
+	MediaAnswered MediaState = "answered"
 )
 
 var actions = map[string]Action{
-	string(ActionAnswer):    ActionAnswer,
-	string(ActionBridge):    ActionBridge,
-	string(ActionCallback):  ActionCallback,
-	string(ActionEvent):     ActionEvent,
-	string(ActionHangup):    ActionHangup,
-	string(ActionTransfer):  ActionTransfer,
-	string(ActionOriginate): ActionOriginate,
-	string(ActionSet):       ActionSet,
+	string(ActionAnswer):           ActionAnswer,
+	string(ActionBridge):           ActionBridge,
+	string(ActionCallback):         ActionCallback,
+	string(ActionEvent):            ActionEvent,
+	string(ActionHangup):           ActionHangup,
+	string(ActionTransfer):         ActionTransfer,
+	string(ActionAttendedTransfer): ActionAttendedTransfer,
+	string(ActionOriginate):        ActionOriginate,
+	string(ActionMenu):             ActionMenu,
+	string(ActionPlayback):         ActionPlayback,
+	string(ActionSet):              ActionSet,
+}
+
+var actionsMu sync.RWMutex
+
+// RegisterAction makes a custom action name recognizable by GetAction.
+func RegisterAction(name string) Action {
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+
+	a := Action(name)
+	actions[name] = a
+
+	return a
 }
 
 type Query string
 
 const (
 	QuerySession Query = "session"
+	QueryState   Query = "state"
+)
+
+type Stage string
+
+const (
+	StageInitializing Stage = "initializing"
+	StageOriginating  Stage = "originating"
+	StageBridged      Stage = "bridged"
+	StageHungup       Stage = "hungup"
 )
 
+var actionStages = map[Action]Stage{
+	ActionOriginate: StageOriginating,
+	ActionBridge:    StageBridged,
+	ActionHangup:    StageHungup,
+}
+
+// StageOf reports the stage a session enters while the action is processed.
+func StageOf(a Action) (Stage, bool) {
+	s, ok := actionStages[a]
+	return s, ok
+}
+
+type WorkflowState struct {
+	Stage     Stage  `json:"stage"`
+	SessionId string `json:"sessionId"`
+	Uid       string `json:"uid"`
+	LastError string `json:"lastError"`
+}
+
 type Metadata map[Field]interface{}
 
+// CopyInto copies every key of m that dst does not set yet.
+func (m *Metadata) CopyInto(dst Metadata) {
+	if m == nil || dst == nil {
+		return
+	}
+
+	for k, v := range *m {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+}
+
 func (m *Metadata) GetAction() Action {
 	if v, ok := (*m)[FieldAction]; ok {
 		if aStr, ok := v.(string); ok {
-			if a, ok := actions[aStr]; ok {
+			actionsMu.RLock()
+			a, ok := actions[aStr]
+			actionsMu.RUnlock()
+
+			if ok {
 				return a
 			}
 		}
@@ -88,6 +187,62 @@ func (m *Metadata) GetInput() WorkflowInput {
 	return WorkflowInput{}
 }
 
+func (m *Metadata) GetString(key Field) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+
+	v, ok := (*m)[key].(string)
+
+	return v, ok
+}
+
+func (m *Metadata) GetInt(key Field) (int, bool) {
+	if m == nil {
+		return 0, false
+	}
+
+	switch v := (*m)[key].(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), true
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), true
+		}
+	}
+
+	return 0, false
+}
+
+func (m *Metadata) GetDuration(key Field) (time.Duration, bool) {
+	if m == nil {
+		return 0, false
+	}
+
+	switch v := (*m)[key].(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, true
+		}
+	}
+
+	if i, ok := m.GetInt(key); ok {
+		return time.Duration(i), true
+	}
+
+	return 0, false
+}
+
 type WorkflowQueryResult map[Field]interface{}
 
 type WorkflowQueryHandler func() (WorkflowQueryResult, error)
@@ -138,34 +293,76 @@ func NewWorkflowOutput(sessionId string) *WorkflowOutput {
 	}
 }
 
+func NewOutput() *WorkflowOutput {
+	return &WorkflowOutput{Metadata: Metadata{}}
+}
+
+func (o *WorkflowOutput) WithSuccess(success bool) *WorkflowOutput {
+	o.Success = success
+	return o
+}
+
+func (o *WorkflowOutput) WithMetadata(key Field, value interface{}) *WorkflowOutput {
+	if o.Metadata == nil {
+		o.Metadata = Metadata{}
+	}
+	o.Metadata[key] = value
+
+	return o
+}
+
+func (o *WorkflowOutput) WithMessage(message string) *WorkflowOutput {
+	return o.WithMetadata(FieldMessage, message)
+}
+
+func (o *WorkflowOutput) Merge(other *WorkflowOutput) *WorkflowOutput {
+	if other == nil {
+		return o
+	}
+
+	if o.SessionId == "" {
+		o.SessionId = other.SessionId
+	}
+
+	for k, v := range other.Metadata {
+		o.WithMetadata(k, v)
+	}
+
+	return o
+}
+
 func Convert(m interface{}, target interface{}) bool {
+	return ConvertE(m, target) == nil
+}
+
+// ConvertE converts m into target through a JSON round trip, naming the
+// offending field when a value has the wrong type.
+func ConvertE(m interface{}, target interface{}) error {
 	jsonData, err := json.Marshal(m)
 	if err != nil {
-		return false
+		return fmt.Errorf("cannot encode %T: %w", m, err)
 	}
 
 	err = json.Unmarshal(jsonData, target)
 	if err != nil {
-		return false
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return fmt.Errorf("cannot convert field '%v' to %v: %w", typeErr.Field, typeErr.Type, err)
+		}
+
+		return fmt.Errorf("cannot decode into %T: %w", target, err)
 	}
 
-	return true
+	return nil
 }
 
 func ConvertInput(in WorkflowInput, out interface{}) bool {
+	return ConvertInputE(in, out) == nil
+}
+
+func ConvertInputE(in WorkflowInput, out interface{}) error {
 	if in["WorkflowInput"] == nil {
 		in["WorkflowInput"] = WorkflowInput{FieldSessionId: in.GetSessionId()}
 	}
 
-	jsonData, err := json.Marshal(in)
-	if err != nil {
-		return false
-	}
-
-	err = json.Unmarshal(jsonData, out)
-	if err != nil {
-		return false
-	}
-
-	return true
+	return ConvertE(in, out)
 }
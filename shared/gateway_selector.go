@@ -0,0 +1,137 @@
+package shared
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+type GatewaySelection string
+
+const (
+	SelectRoundRobin        GatewaySelection = "round_robin"
+	SelectWeighted          GatewaySelection = "weighted"
+	SelectLeastRecentlyUsed GatewaySelection = "least_recently_used"
+)
+
+// GatewaySelector picks the gateway to dial first out of a non-empty list.
+type GatewaySelector interface {
+	Select(gateways []string) string
+}
+
+// RoundRobinSelector cycles through each distinct list of gateways.
+type RoundRobinSelector struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{next: make(map[string]int)}
+}
+
+func (s *RoundRobinSelector) Select(gateways []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.Join(gateways, ",")
+	n := s.next[key] % len(gateways)
+	s.next[key] = n + 1
+
+	return gateways[n]
+}
+
+// WeightedSelector picks gateways at random in proportion to their weight,
+// gateways without one weighing 1 and those weighing 0 or less never picked.
+type WeightedSelector struct {
+	Weights map[string]int
+}
+
+func NewWeightedSelector(weights map[string]int) *WeightedSelector {
+	return &WeightedSelector{Weights: weights}
+}
+
+func (s *WeightedSelector) weight(gateway string) int {
+	if w, ok := s.Weights[gateway]; ok {
+		return w
+	}
+
+	return 1
+}
+
+func (s *WeightedSelector) Select(gateways []string) string {
+	total := 0
+	for _, g := range gateways {
+		if w := s.weight(g); w > 0 {
+			total += w
+		}
+	}
+
+	if total == 0 {
+		return gateways[0]
+	}
+
+	n := rand.Intn(total)
+	for _, g := range gateways {
+		if w := s.weight(g); w > 0 {
+			if n < w {
+				return g
+			}
+			n -= w
+		}
+	}
+
+	return gateways[len(gateways)-1]
+}
+
+// LeastRecentlyUsedSelector picks the gateway it handed out the longest ago,
+// preferring ones it never did in list order.
+type LeastRecentlyUsedSelector struct {
+	mu   sync.Mutex
+	seq  uint64
+	used map[string]uint64
+}
+
+func NewLeastRecentlyUsedSelector() *LeastRecentlyUsedSelector {
+	return &LeastRecentlyUsedSelector{used: make(map[string]uint64)}
+}
+
+func (s *LeastRecentlyUsedSelector) Select(gateways []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pick := gateways[0]
+	for _, g := range gateways[1:] {
+		if s.used[g] < s.used[pick] {
+			pick = g
+		}
+	}
+
+	s.seq++
+	s.used[pick] = s.seq
+
+	return pick
+}
+
+var (
+	roundRobinGateways        = NewRoundRobinSelector()
+	leastRecentlyUsedGateways = NewLeastRecentlyUsedSelector()
+)
+
+// NewGatewaySelector returns the selector for selection. Round robin and least
+// recently used keep their state across calls, weights only apply to weighted.
+func NewGatewaySelector(selection GatewaySelection, weights map[string]int) (GatewaySelector, bool) {
+	switch selection {
+	case SelectRoundRobin:
+		return roundRobinGateways, true
+	case SelectWeighted:
+		return NewWeightedSelector(weights), true
+	case SelectLeastRecentlyUsed:
+		return leastRecentlyUsedGateways, true
+	default:
+		return nil, false
+	}
+}
+
+var _ GatewaySelector = (*RoundRobinSelector)(nil)
+var _ GatewaySelector = (*WeightedSelector)(nil)
+var _ GatewaySelector = (*LeastRecentlyUsedSelector)(nil)
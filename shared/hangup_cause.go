@@ -0,0 +1,86 @@
+package shared
+
+type HangupCause string
+
+const (
+	HangupUnspecified             HangupCause = "UNSPECIFIED"
+	HangupUnallocatedNumber       HangupCause = "UNALLOCATED_NUMBER"
+	HangupNoRouteTransitNet       HangupCause = "NO_ROUTE_TRANSIT_NET"
+	HangupNoRouteDestination      HangupCause = "NO_ROUTE_DESTINATION"
+	HangupNormalClearing          HangupCause = "NORMAL_CLEARING"
+	HangupUserBusy                HangupCause = "USER_BUSY"
+	HangupNoUserResponse          HangupCause = "NO_USER_RESPONSE"
+	HangupNoAnswer                HangupCause = "NO_ANSWER"
+	HangupSubscriberAbsent        HangupCause = "SUBSCRIBER_ABSENT"
+	HangupCallRejected            HangupCause = "CALL_REJECTED"
+	HangupNumberChanged           HangupCause = "NUMBER_CHANGED"
+	HangupDestinationOutOfOrder   HangupCause = "DESTINATION_OUT_OF_ORDER"
+	HangupInvalidNumberFormat     HangupCause = "INVALID_NUMBER_FORMAT"
+	HangupFacilityRejected        HangupCause = "FACILITY_REJECTED"
+	HangupNormalUnspecified       HangupCause = "NORMAL_UNSPECIFIED"
+	HangupNormalCircuitCongestion HangupCause = "NORMAL_CIRCUIT_CONGESTION"
+	HangupNetworkOutOfOrder       HangupCause = "NETWORK_OUT_OF_ORDER"
+	HangupNormalTemporaryFailure  HangupCause = "NORMAL_TEMPORARY_FAILURE"
+	HangupSwitchCongestion        HangupCause = "SWITCH_CONGESTION"
+	HangupIncompatibleDestination HangupCause = "INCOMPATIBLE_DESTINATION"
+	HangupRecoveryOnTimerExpire   HangupCause = "RECOVERY_ON_TIMER_EXPIRE"
+	HangupOriginatorCancel        HangupCause = "ORIGINATOR_CANCEL"
+	HangupAllottedTimeout         HangupCause = "ALLOTTED_TIMEOUT"
+	HangupUserNotRegistered       HangupCause = "USER_NOT_REGISTERED"
+	HangupGatewayDown             HangupCause = "GATEWAY_DOWN"
+	HangupMediaTimeout            HangupCause = "MEDIA_TIMEOUT"
+	HangupLoseRace                HangupCause = "LOSE_RACE"
+	HangupManagerRequest          HangupCause = "MANAGER_REQUEST"
+	HangupSystemShutdown          HangupCause = "SYSTEM_SHUTDOWN"
+	HangupNoSuchChannel           HangupCause = "NO_SUCH_CHANNEL"
+)
+
+// hangupCauses maps every known cause to whether it is terminal, i.e. whether
+// the far end (or the number itself) decided the outcome so retrying the same
+// destination on another route would not change it.
+var hangupCauses = map[HangupCause]bool{
+	HangupUnspecified:             false,
+	HangupUnallocatedNumber:       true,
+	HangupNoRouteTransitNet:       false,
+	HangupNoRouteDestination:      false,
+	HangupNormalClearing:          true,
+	HangupUserBusy:                true,
+	HangupNoUserResponse:          true,
+	HangupNoAnswer:                true,
+	HangupSubscriberAbsent:        true,
+	HangupCallRejected:            true,
+	HangupNumberChanged:           true,
+	HangupDestinationOutOfOrder:   false,
+	HangupInvalidNumberFormat:     true,
+	HangupFacilityRejected:        false,
+	HangupNormalUnspecified:       false,
+	HangupNormalCircuitCongestion: false,
+	HangupNetworkOutOfOrder:       false,
+	HangupNormalTemporaryFailure:  false,
+	HangupSwitchCongestion:        false,
+	HangupIncompatibleDestination: false,
+	HangupRecoveryOnTimerExpire:   false,
+	HangupOriginatorCancel:        true,
+	HangupAllottedTimeout:         true,
+	HangupUserNotRegistered:       true,
+	HangupGatewayDown:             false,
+	HangupMediaTimeout:            false,
+	HangupLoseRace:                true,
+	HangupManagerRequest:          true,
+	HangupSystemShutdown:          false,
+	HangupNoSuchChannel:           true,
+}
+
+func (c HangupCause) IsValid() bool {
+	_, ok := hangupCauses[c]
+	return ok
+}
+
+func ParseHangupCause(s string) (HangupCause, bool) {
+	c := HangupCause(s)
+	return c, c.IsValid()
+}
+
+func IsTerminal(cause HangupCause) bool {
+	return hangupCauses[cause]
+}
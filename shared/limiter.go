@@ -0,0 +1,97 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrCapacityExceeded = errors.New("capacity exceeded")
+
+type LimiterConfig struct {
+	MaxConcurrent int           `json:"maxConcurrent"`
+	WaitTimeout   time.Duration `json:"waitTimeout"`
+}
+
+const defaultLimiterWait = 10 * time.Second
+
+// Limiter bounds how many callers hold a slot at once, callers waiting at
+// most WaitTimeout for one. It counts the slots in use even when unbounded.
+type Limiter struct {
+	slots chan struct{}
+	wait  time.Duration
+	inUse atomic.Int64
+}
+
+// NewLimiter returns a limiter of c.MaxConcurrent slots, unbounded when that
+// is not positive.
+func NewLimiter(c LimiterConfig) *Limiter {
+	if c.WaitTimeout <= 0 {
+		c.WaitTimeout = defaultLimiterWait
+	}
+
+	l := &Limiter{wait: c.WaitTimeout}
+	if c.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, c.MaxConcurrent)
+	}
+
+	return l
+}
+
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			timer := time.NewTimer(l.wait)
+			defer timer.Stop()
+
+			select {
+			case l.slots <- struct{}{}:
+			case <-timer.C:
+				return ErrCapacityExceeded
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	l.inUse.Add(1)
+
+	return nil
+}
+
+func (l *Limiter) Release() {
+	l.inUse.Add(-1)
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *Limiter) InUse() int {
+	return int(l.inUse.Load())
+}
+
+var (
+	limiterMu        sync.RWMutex
+	originateLimiter = NewLimiter(LimiterConfig{})
+)
+
+// OriginateLimiter bounds the originates OriginateActivity runs at once.
+func OriginateLimiter() *Limiter {
+	limiterMu.RLock()
+	defer limiterMu.RUnlock()
+
+	return originateLimiter
+}
+
+func SetOriginateLimiter(l *Limiter) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+
+	if l == nil {
+		l = NewLimiter(LimiterConfig{})
+	}
+	originateLimiter = l
+}
@@ -0,0 +1,23 @@
+package shared
+
+import (
+	"context"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+)
+
+func SessionField(sessionId string) zap.Field {
+	return zap.String("sessionId", sessionId)
+}
+
+// ActivityLogger returns the activity logger carrying the fields every activity
+// log line is searched by: activity name, workflow and run id.
+func ActivityLogger(ctx context.Context, name string, fields ...zap.Field) *zap.Logger {
+	info := activity.GetInfo(ctx)
+
+	return activity.GetLogger(ctx).With(append([]zap.Field{
+		zap.String("activityName", name),
+		zap.String("workflowId", info.WorkflowExecution.ID),
+		zap.String("runId", info.WorkflowExecution.RunID),
+	}, fields...)...)
+}
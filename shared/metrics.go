@@ -0,0 +1,47 @@
+package shared
+
+import (
+	"sync"
+	"time"
+)
+
+type MetricsReporter interface {
+	ActivityStarted(name string)
+	ActivityCompleted(name string, duration time.Duration, success bool)
+}
+
+type noopReporter struct{}
+
+func (noopReporter) ActivityStarted(string) {}
+
+func (noopReporter) ActivityCompleted(string, time.Duration, bool) {}
+
+var (
+	metricsMu sync.RWMutex
+	metrics   MetricsReporter = noopReporter{}
+)
+
+func SetMetricsReporter(r MetricsReporter) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	if r == nil {
+		r = noopReporter{}
+	}
+	metrics = r
+}
+
+// ReportActivity marks an activity as started and returns the func to call
+// with its outcome once it completes.
+func ReportActivity(name string) func(success bool) {
+	metricsMu.RLock()
+	r := metrics
+	metricsMu.RUnlock()
+
+	r.ActivityStarted(name)
+	start := time.Now()
+
+	return func(success bool) {
+		r.ActivityCompleted(name, time.Since(start), success)
+	}
+}
@@ -0,0 +1,78 @@
+package shared
+
+import (
+	"fmt"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+)
+
+// Registrar registers workflows and activities under their Name(), so the
+// alias used at execution always matches the registered one.
+type Registrar struct {
+	workflows  []FreeswitchWorkflow
+	activities []FreeswitchActivity
+}
+
+func NewRegistrar() *Registrar {
+	return &Registrar{}
+}
+
+func (r *Registrar) AddWorkflow(ws ...FreeswitchWorkflow) *Registrar {
+	for _, w := range ws {
+		if w != nil {
+			r.workflows = append(r.workflows, w)
+		}
+	}
+
+	return r
+}
+
+func (r *Registrar) AddActivity(as ...FreeswitchActivity) *Registrar {
+	for _, a := range as {
+		if a != nil {
+			r.activities = append(r.activities, a)
+		}
+	}
+
+	return r
+}
+
+func (r *Registrar) Register(reg worker.Registry) error {
+	seen := make(map[string]bool)
+	for _, w := range r.workflows {
+		if err := checkName(seen, "workflow", w.Name()); err != nil {
+			return err
+		}
+	}
+
+	seen = make(map[string]bool)
+	for _, a := range r.activities {
+		if err := checkName(seen, "activity", a.Name()); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range r.workflows {
+		reg.RegisterWorkflowWithOptions(w.Handler(), workflow.RegisterOptions{Name: w.Name()})
+	}
+
+	for _, a := range r.activities {
+		reg.RegisterActivityWithOptions(a.Handler(), activity.RegisterOptions{Name: a.Name()})
+	}
+
+	return nil
+}
+
+func checkName(seen map[string]bool, kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%v has no name", kind)
+	}
+
+	if seen[name] {
+		return fmt.Errorf("%v '%v' registered twice", kind, name)
+	}
+	seen[name] = true
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package shared
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/workflow"
+	"time"
+)
+
+const ReasonWorkflowInput = "fsflow:WorkflowInputError"
+
+type ActivityRetryConfig struct {
+	InitialInterval          time.Duration `json:"initialInterval"`
+	BackoffCoefficient       float64       `json:"backoffCoefficient"`
+	MaximumInterval          time.Duration `json:"maximumInterval"`
+	ExpirationInterval       time.Duration `json:"expirationInterval"`
+	MaximumAttempts          int32         `json:"maximumAttempts"`
+	NonRetriableErrorReasons []string      `json:"nonRetriableErrorReasons"`
+}
+
+var DefaultActivityRetryConfig = ActivityRetryConfig{
+	InitialInterval:    time.Second,
+	BackoffCoefficient: 2.0,
+	MaximumInterval:    10 * time.Second,
+	ExpirationInterval: time.Minute,
+	MaximumAttempts:    3,
+}
+
+func (c *ActivityRetryConfig) RetryPolicy() *cadence.RetryPolicy {
+	if c == nil {
+		c = &DefaultActivityRetryConfig
+	}
+
+	reasons := append([]string{ReasonWorkflowInput}, c.NonRetriableErrorReasons...)
+
+	return &cadence.RetryPolicy{
+		InitialInterval:          c.InitialInterval,
+		BackoffCoefficient:       c.BackoffCoefficient,
+		MaximumInterval:          c.MaximumInterval,
+		ExpirationInterval:       c.ExpirationInterval,
+		MaximumAttempts:          c.MaximumAttempts,
+		NonRetriableErrorReasons: reasons,
+	}
+}
+
+func NewActivityOptions(timeout time.Duration, c *ActivityRetryConfig) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Second,
+		StartToCloseTimeout:    timeout,
+		RetryPolicy:            c.RetryPolicy(),
+	}
+}
+
+// NonRetryable turns input errors into a cadence custom error carrying
+// ReasonWorkflowInput, so retry policies built here never retry them.
+func NonRetryable(err error) error {
+	switch err.(type) {
+	case *errors.WorkflowInputError, *errors.MissingArgError:
+		return cadence.NewCustomError(ReasonWorkflowInput, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,41 @@
+package shared
+
+import (
+	"context"
+	"sync"
+)
+
+// SessionInitializer sets a new session up, e.g. by looking the caller up or
+// routing the call, and returns the metadata to process first. The context
+// expires before the init timeout so a hangup can still be reported.
+type SessionInitializer interface {
+	Init(ctx context.Context, input WorkflowInput) (*WorkflowOutput, error)
+}
+
+type SessionInitializerFunc func(ctx context.Context, input WorkflowInput) (*WorkflowOutput, error)
+
+func (f SessionInitializerFunc) Init(ctx context.Context, input WorkflowInput) (*WorkflowOutput, error) {
+	return f(ctx, input)
+}
+
+var (
+	initializersMu sync.RWMutex
+	initializers   = map[string]SessionInitializer{}
+)
+
+// RegisterSessionInitializer makes SessionInitActivity dispatch to init when
+// the initializer name is used, instead of posting the session to it as a URL.
+func RegisterSessionInitializer(name string, init SessionInitializer) {
+	initializersMu.Lock()
+	defer initializersMu.Unlock()
+
+	initializers[name] = init
+}
+
+func LookupSessionInitializer(name string) (SessionInitializer, bool) {
+	initializersMu.RLock()
+	defer initializersMu.RUnlock()
+
+	init, ok := initializers[name]
+	return init, ok && init != nil
+}
@@ -0,0 +1,96 @@
+package sharedtest
+
+import (
+	"github.com/luongdev/fsflow/shared"
+	"sync"
+	"time"
+)
+
+// Clock is a shared.Clock that only moves when told to, firing the timers
+// that fall due on the way.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) NewTimer(d time.Duration) shared.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{c: make(chan time.Time, 1), at: c.now.Add(d), clock: c}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+}
+
+// Waiters reports how many timers have yet to fire.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+func (c *Clock) stop(t *timer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+type timer struct {
+	c     chan time.Time
+	at    time.Time
+	clock *Clock
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	return t.clock.stop(t)
+}
+
+var _ shared.Clock = (*Clock)(nil)
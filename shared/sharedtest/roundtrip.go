@@ -0,0 +1,55 @@
+package sharedtest
+
+import (
+	"github.com/luongdev/fsflow/shared"
+	"reflect"
+	"testing"
+)
+
+// AssertRoundTrip passes value through a WorkflowInput and back into a fresh
+// value of the same type, the way workflows hand inputs to activities, and
+// fails t for every field that did not survive the trip.
+func AssertRoundTrip(t testing.TB, value interface{}) {
+	t.Helper()
+
+	typ := reflect.TypeOf(value)
+	if typ == nil {
+		t.Fatalf("cannot round trip a nil value")
+	}
+
+	want := reflect.ValueOf(value)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		want = want.Elem()
+	}
+
+	in := shared.WorkflowInput{}
+	if err := shared.ConvertE(value, &in); err != nil {
+		t.Fatalf("cannot convert %T to WorkflowInput: %v", value, err)
+	}
+
+	got := reflect.New(typ)
+	if err := shared.ConvertE(in, got.Interface()); err != nil {
+		t.Fatalf("cannot convert WorkflowInput back to %v: %v", typ, err)
+	}
+
+	if reflect.DeepEqual(want.Interface(), got.Elem().Interface()) {
+		return
+	}
+
+	if typ.Kind() != reflect.Struct {
+		t.Errorf("%v did not survive the round trip: want %+v, got %+v", typ, want.Interface(), got.Elem().Interface())
+		return
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		w, g := want.Field(i), got.Elem().Field(i)
+		if !f.IsExported() || reflect.DeepEqual(w.Interface(), g.Interface()) {
+			continue
+		}
+
+		t.Errorf("%v.%v (json %q) did not survive the round trip: want %+v, got %+v",
+			typ, f.Name, f.Tag.Get("json"), w.Interface(), g.Interface())
+	}
+}
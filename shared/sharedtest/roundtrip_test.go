@@ -0,0 +1,78 @@
+package sharedtest
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// recorder is a testing.TB collecting the failures AssertRoundTrip reports.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func record(t *testing.T, value interface{}) []string {
+	r := &recorder{TB: t}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AssertRoundTrip(r, value)
+	}()
+	<-done
+
+	return r.failures
+}
+
+type kept struct {
+	Name  string            `json:"name"`
+	Count int               `json:"count"`
+	Tags  []string          `json:"tags"`
+	Vars  map[string]string `json:"vars"`
+}
+
+type dropping struct {
+	Name    string `json:"name"`
+	Dropped string `json:"-"`
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	value := kept{Name: "a", Count: 2, Tags: []string{"x"}, Vars: map[string]string{"k": "v"}}
+	if failures := record(t, value); len(failures) != 0 {
+		t.Fatalf("expected no failure, got %v", failures)
+	}
+
+	if failures := record(t, &value); len(failures) != 0 {
+		t.Fatalf("expected no failure for a pointer, got %v", failures)
+	}
+}
+
+func TestAssertRoundTripDroppedField(t *testing.T) {
+	failures := record(t, dropping{Name: "a", Dropped: "b"})
+	if len(failures) != 1 {
+		t.Fatalf("expected one failure, got %v", failures)
+	}
+
+	want := `sharedtest.dropping.Dropped (json "-") did not survive the round trip: want b, got `
+	if failures[0] != want {
+		t.Fatalf("unexpected failure %q", failures[0])
+	}
+}
+
+func TestAssertRoundTripNil(t *testing.T) {
+	if failures := record(t, nil); len(failures) != 1 {
+		t.Fatalf("expected a nil value to fail, got %v", failures)
+	}
+}
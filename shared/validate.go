@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"github.com/luongdev/fsflow/errors"
+	"reflect"
+	"strings"
+)
+
+// Validate checks the fields of a struct tagged `validate:"required"` and
+// reports the first one left at its zero value by its json name.
+func Validate(target interface{}) error {
+	v := reflect.ValueOf(target)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return errors.NewWorkflowInputError("cannot validate nil input")
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := Validate(v.Field(i).Interface()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !hasRule(f.Tag.Get("validate"), "required") {
+			continue
+		}
+
+		if v.Field(i).IsZero() {
+			return errors.RequireField(fieldName(f))
+		}
+	}
+
+	return nil
+}
+
+func hasRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if strings.TrimSpace(r) == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+
+	return name
+}
@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"go.uber.org/cadence/workflow"
+)
+
+// Change ids guarding logic added to running workflows. Once recorded in a
+// history they must never be renamed or removed while such runs may replay.
+const (
+	ChangeInboundCallerHangup   = "inbound-caller-hangup"
+	ChangeInboundCarryMetadata  = "inbound-carry-metadata"
+	ChangeInboundCancellation   = "inbound-cancellation"
+	ChangeVoicemailMessageId    = "voicemail-message-id"
+	ChangeOriginateUniqueId     = "originate-unique-id"
+	ChangeTransferParkLegs      = "transfer-park-legs"
+	ChangeCallbackKillAgent     = "callback-kill-agent"
+	ChangeQueueConnectAgent     = "queue-connect-agent"
+	ChangeOriginateKillLeg      = "originate-kill-leg"
+	ChangeOriginateCallerHangup = "originate-caller-hangup"
+	ChangeOriginateReleaseHold  = "originate-release-hold"
+)
+
+// HasChange reports whether the run takes the branch introduced by changeId.
+// New runs record the change and take it, runs started before it replay with
+// workflow.DefaultVersion and keep the old behaviour:
+//
+//	if shared.HasChange(ctx, shared.ChangeSomething) {
+//		// new logic
+//	} else {
+//		// logic as it was before the change
+//	}
+//
+// Check it outside loops and before the changed commands are issued, once
+// every old run has completed the else branch can go, never the id itself.
+func HasChange(ctx workflow.Context, changeId string) bool {
+	return workflow.GetVersion(ctx, changeId, workflow.DefaultVersion, 1) >= 1
+}
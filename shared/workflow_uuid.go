@@ -0,0 +1,17 @@
+package shared
+
+import (
+	"github.com/google/uuid"
+	"go.uber.org/cadence/workflow"
+)
+
+// WorkflowUUID generates a UUID inside workflow code. The value is recorded
+// in the history, so replays get the same one instead of a fresh uuid.New.
+func WorkflowUUID(ctx workflow.Context) (string, error) {
+	var id string
+	err := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return uuid.New().String()
+	}).Get(&id)
+
+	return id, err
+}
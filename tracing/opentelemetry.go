@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type openTelemetrySpan struct {
+	trace.Span
+}
+
+func (s openTelemetrySpan) SetTag(key string, value interface{}) {
+	s.Span.SetAttributes(attributeOf(key, value))
+}
+
+func (s openTelemetrySpan) Finish() {
+	s.Span.End()
+}
+
+// OpenTelemetryTracer starts spans as children of the span found in the
+// context, so activity spans nest under whatever the worker propagates.
+type OpenTelemetryTracer struct {
+	tracer trace.Tracer
+}
+
+// NewOpenTelemetryTracer traces through tracer, or the global tracer provider
+// registered with otel.SetTracerProvider when nil.
+func NewOpenTelemetryTracer(tracer trace.Tracer) *OpenTelemetryTracer {
+	if tracer == nil {
+		tracer = otel.Tracer("github.com/luongdev/fsflow")
+	}
+
+	return &OpenTelemetryTracer{tracer: tracer}
+}
+
+func (t *OpenTelemetryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, name)
+
+	return ctx, openTelemetrySpan{Span: span}
+}
+
+func attributeOf(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	default:
+		return attribute.String(key, fmt.Sprintf("%v", v))
+	}
+}
+
+var _ Tracer = (*OpenTelemetryTracer)(nil)
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+type Span interface {
+	SetTag(key string, value interface{})
+	Finish()
+}
+
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetTag(string, interface{}) {}
+
+func (noopSpan) Finish() {}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+var (
+	mu     sync.RWMutex
+	tracer Tracer = noopTracer{}
+)
+
+// SetTracer installs the tracer used for activity and Api spans. Without one
+// spans are no-ops.
+func SetTracer(t Tracer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if t == nil {
+		t = noopTracer{}
+	}
+	tracer = t
+}
+
+func Start(ctx context.Context, name string) (context.Context, Span) {
+	mu.RLock()
+	t := tracer
+	mu.RUnlock()
+
+	return t.Start(ctx, name)
+}
+
+func StartActivity(ctx context.Context, name, sessionId string) (context.Context, Span) {
+	ctx, span := Start(ctx, name)
+	span.SetTag("activity", name)
+	span.SetTag("sessionId", sessionId)
+
+	return ctx, span
+}
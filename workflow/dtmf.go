@@ -0,0 +1,24 @@
+package workflow
+
+import (
+	"context"
+	"github.com/luongdev/fsflow/freeswitch"
+	"go.uber.org/cadence/client"
+	"log"
+	"time"
+)
+
+const DtmfSignal = "dtmf"
+
+// ForwardDtmf signals every digit pressed on the session to the workflow as a
+// DtmfSignal and returns the complete string once the collector finishes.
+func ForwardDtmf(ctx context.Context, c client.Client, fs freeswitch.SocketClient, workflowId, sessionId, terminator string, timeout time.Duration) (string, error) {
+	collector := freeswitch.NewDtmfCollector(fs, sessionId, terminator, timeout)
+	collector.OnDigit = func(digit string) {
+		if err := c.SignalWorkflow(ctx, workflowId, "", DtmfSignal, digit); err != nil {
+			log.Printf("Failed to signal digit to workflow %v: %v", workflowId, err)
+		}
+	}
+
+	return collector.Collect(ctx)
+}
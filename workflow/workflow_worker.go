@@ -6,11 +6,10 @@ import (
 	"github.com/luongdev/fsflow/session/activities"
 	"github.com/luongdev/fsflow/session/workflows"
 	"github.com/luongdev/fsflow/shared"
+	"github.com/luongdev/fsflow/tracing"
 	"github.com/uber-go/tally"
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
-	"go.uber.org/cadence/activity"
 	"go.uber.org/cadence/worker"
-	"go.uber.org/cadence/workflow"
 	"io"
 	"os"
 	"time"
@@ -19,6 +18,10 @@ import (
 type FreeswitchWorkerOptions struct {
 	Domain         string
 	SocketProvider freeswitch.SocketProvider
+	Metrics        shared.MetricsReporter
+	Tracer         tracing.Tracer
+	GatewayBreaker *shared.BreakerConfig
+	OriginateLimit *shared.LimiterConfig
 }
 
 type FreeswitchWorker struct {
@@ -65,9 +68,31 @@ func NewFreeswitchWorker(c *Config, opts *FreeswitchWorkerOptions) (*FreeswitchW
 		store:          session.NewWorkflowStore(),
 	}
 
+	if opts.Metrics != nil {
+		shared.SetMetricsReporter(opts.Metrics)
+	}
+
+	if opts.Tracer != nil {
+		tracing.SetTracer(opts.Tracer)
+	}
+
+	if opts.GatewayBreaker != nil {
+		shared.SetGatewayBreaker(shared.NewCircuitBreaker(*opts.GatewayBreaker))
+	}
+
+	if opts.OriginateLimit != nil {
+		shared.SetOriginateLimiter(shared.NewLimiter(*opts.OriginateLimit))
+	}
+
 	aP := session.NewActivityProvider(fsWorker.store)
 
 	fsWorker.AddWorkflow(workflows.NewInboundWorkflow(opts.SocketProvider, aP))
+	fsWorker.AddWorkflow(workflows.NewOutboundWorkflow(opts.SocketProvider, aP))
+	fsWorker.AddWorkflow(workflows.NewIVRWorkflow(opts.SocketProvider, aP))
+	fsWorker.AddWorkflow(workflows.NewVoicemailWorkflow(opts.SocketProvider, aP))
+	fsWorker.AddWorkflow(workflows.NewQueueWorkflow(opts.SocketProvider, aP))
+	fsWorker.AddWorkflow(workflows.NewCallbackWorkflow(opts.SocketProvider, aP))
+	fsWorker.AddWorkflow(workflows.NewConsultAndTransferWorkflow(opts.SocketProvider, aP))
 
 	fsWorker.AddActivity(activities.NewCallbackActivity())
 	fsWorker.AddActivity(activities.NewSessionInitActivity())
@@ -75,17 +100,43 @@ func NewFreeswitchWorker(c *Config, opts *FreeswitchWorkerOptions) (*FreeswitchW
 	fsWorker.AddActivity(activities.NewBridgeActivity(opts.SocketProvider))
 	fsWorker.AddActivity(activities.NewHangupActivity(opts.SocketProvider))
 	fsWorker.AddActivity(activities.NewOriginateActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewPlaybackActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewCollectDigitsActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewRecordSessionActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewTransferActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewAnswerActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewPreAnswerActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewSetVariableActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewGetVariableActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewConferenceActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewSendDTMFActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewParkActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewHoldActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewSpeakActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewScheduleHangupActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewStreamAudioActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewDialplanExecuteActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewMuteActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewSupervisorBargeActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewRingbackActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewKillActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewOriginateAndBridgeActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewSetCallerIdActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewDeflectActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewRecordConferenceActivity(opts.SocketProvider))
+	fsWorker.AddActivity(activities.NewDisplaceActivity(opts.SocketProvider))
 
 	return fsWorker, nil
 }
 
 func (w *FreeswitchWorker) Start() error {
+	r := shared.NewRegistrar()
 	for _, wName := range w.workflows {
 		ww, err := w.store.GetWorkflow(wName)
 		if err != nil {
 			return err
 		}
-		w.Worker.RegisterWorkflowWithOptions(ww.Handler(), workflow.RegisterOptions{Name: ww.Name()})
+		r.AddWorkflow(ww)
 	}
 
 	for _, aName := range w.activities {
@@ -93,7 +144,11 @@ func (w *FreeswitchWorker) Start() error {
 		if err != nil {
 			return err
 		}
-		w.Worker.RegisterActivityWithOptions(wa.Handler(), activity.RegisterOptions{Name: wa.Name()})
+		r.AddActivity(wa)
+	}
+
+	if err := r.Register(w.Worker); err != nil {
+		return err
 	}
 
 	err := w.Worker.Start()